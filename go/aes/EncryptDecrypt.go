@@ -14,7 +14,8 @@ limitations under the License.
 */
 
 // Package aes provides AES-256 encryption and decryption utilities for the Layer 8 system.
-// Uses CFB (Cipher Feedback) mode with a random IV (Initialization Vector) for each encryption.
+// Uses CFB (Cipher Feedback) mode with a random IV (Initialization Vector) for each encryption,
+// or GCM (Galois/Counter Mode) with a random nonce for authenticated encryption.
 // Encrypted data is returned as base64-encoded strings for safe transmission.
 package aes
 
@@ -101,3 +102,63 @@ func Decrypt(stringToDecode, key string) ([]byte, error) {
 	cfb.XORKeyStream(data, encData)
 	return data, nil
 }
+
+// EncryptAEAD encrypts and authenticates data using AES in GCM mode.
+// The additional data is authenticated but not encrypted, and must be passed
+// unchanged to DecryptAEAD. A random nonce is prepended to the ciphertext.
+// Parameters:
+//   - dataToEncode: The plaintext data to encrypt
+//   - additionalData: Data to authenticate alongside the ciphertext (may be nil)
+//   - key: A 16, 24 or 32 character AES key
+//
+// Returns the base64-encoded ciphertext (nonce + encrypted data + tag).
+func EncryptAEAD(dataToEncode, additionalData []byte, key string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := gcm.NonceSize()
+	cipherdata := make([]byte, nonceSize, nonceSize+len(dataToEncode)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, cipherdata); err != nil {
+		return "", err
+	}
+
+	cipherdata = gcm.Seal(cipherdata, cipherdata[:nonceSize], dataToEncode, additionalData)
+	return base64.StdEncoding.EncodeToString(cipherdata), nil
+}
+
+// DecryptAEAD decrypts and verifies a base64-encoded AES-GCM ciphertext.
+// Fails if the ciphertext or the additional data were modified.
+// Parameters:
+//   - stringToDecode: The base64-encoded ciphertext (nonce + encrypted data + tag)
+//   - additionalData: The same additional data passed to EncryptAEAD
+//   - key: The same key used for encryption
+//
+// Returns the decrypted plaintext data.
+func DecryptAEAD(stringToDecode string, additionalData []byte, key string) ([]byte, error) {
+	encData, err := base64.StdEncoding.DecodeString(stringToDecode)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(encData) < nonceSize+gcm.Overhead() {
+		return nil, errors.New("encrypted data is too short")
+	}
+	return gcm.Open(nil, encData[:nonceSize], encData[nonceSize:], additionalData)
+}
+
+// newGCM creates an AES-GCM cipher for the given key.
+func newGCM(key string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,200 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// l8inspect decodes captured Layer 8 frames and prints them as JSON.
+//
+// Usage:
+//
+//	l8inspect [-key base64-aes-key | -secret secret] [-format framed|capture|raw|hex] [-type payload-type] [file]
+//
+// The input is read from the file, or stdin when no file is given. The framed
+// format is the 8 byte length prefixed stream written by nets.Write, capture is
+// a capture file written by nets.Capture, raw is a single frame and hex is a
+// single hex encoded frame. Frames that cannot be decrypted are printed with
+// their routing header and the error.
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+)
+
+// inspectResources provides the security provider to Message.Unmarshal.
+// Only Security and Registry are used when decoding frames.
+type inspectResources struct {
+	ifs.IResources
+	security ifs.ISecurityProvider
+}
+
+func (this *inspectResources) Security() ifs.ISecurityProvider { return this.security }
+func (this *inspectResources) Registry() ifs.IRegistry         { return nil }
+
+// capturedFrame is printed for the frames of a capture file.
+type capturedFrame struct {
+	Time      time.Time   `json:"time"`
+	Direction string      `json:"direction"`
+	Frame     interface{} `json:"frame"`
+}
+
+// frameHeader is printed for frames that cannot be decoded.
+type frameHeader struct {
+	Source      string `json:"source"`
+	Vnet        string `json:"vnet"`
+	Destination string `json:"destination"`
+	ServiceName string `json:"serviceName"`
+	ServiceArea byte   `json:"serviceArea"`
+	Priority    string `json:"priority"`
+	Version     byte   `json:"version"`
+	Size        int    `json:"size"`
+	Error       string `json:"error"`
+}
+
+func main() {
+	key := flag.String("key", "", "base64 encoded AES key of the security provider")
+	secret := flag.String("secret", "", "secret the security provider key is derived from")
+	format := flag.String("format", "framed", "input format: framed, capture, raw or hex")
+	payloadType := flag.String("type", "", "type name to decode the payload as, e.g. l8api.L8Query")
+	flag.Parse()
+
+	var security ifs.ISecurityProvider
+	switch {
+	case *key != "":
+		security = sec.NewShallowSecurityProviderWithKey(*key)
+	case *secret != "":
+		security = sec.NewShallowSecurityProviderWithSecret(*secret)
+	default:
+		security = sec.NewShallowSecurityProvider()
+	}
+
+	in := io.Reader(os.Stdin)
+	if flag.NArg() > 0 {
+		file, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	resources := &inspectResources{security: security}
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	var err error
+	if *format == "capture" {
+		err = inspectCapture(in, out, resources, *payloadType)
+	} else {
+		var frames [][]byte
+		frames, err = readFrames(bufio.NewReader(in), *format)
+		for _, frame := range frames {
+			out.Encode(inspect(frame, resources, *payloadType))
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// inspectCapture prints the frames of a capture file with their time and direction.
+func inspectCapture(in io.Reader, out *json.Encoder, resources ifs.IResources, payloadType string) error {
+	reader, err := nets.NewCaptureReader(in)
+	if err != nil {
+		return err
+	}
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		direction := "in"
+		if record.Direction == nets.CaptureOut {
+			direction = "out"
+		}
+		out.Encode(&capturedFrame{Time: record.Time, Direction: direction, Frame: inspect(record.Data, resources, payloadType)})
+	}
+}
+
+// readFrames reads all frames of the input in the format.
+func readFrames(in *bufio.Reader, format string) ([][]byte, error) {
+	switch format {
+	case "raw":
+		data, err := io.ReadAll(in)
+		return [][]byte{data}, err
+	case "hex":
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return nil, err
+		}
+		frame, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		return [][]byte{frame}, err
+	case "framed":
+		var frames [][]byte
+		for {
+			size := make([]byte, 8)
+			if _, err := io.ReadFull(in, size); err != nil {
+				if err == io.EOF {
+					return frames, nil
+				}
+				return frames, err
+			}
+			n := ifs.Bytes2Long(size)
+			if n < 0 || n > 1<<32 {
+				return frames, fmt.Errorf("invalid frame size %d", n)
+			}
+			frame := make([]byte, n)
+			if _, err := io.ReadFull(in, frame); err != nil {
+				return frames, err
+			}
+			frames = append(frames, frame)
+		}
+	}
+	return nil, errors.New("unknown format " + format)
+}
+
+// inspect decodes a frame, falling back to its routing header when the body cannot be decoded.
+func inspect(frame []byte, resources ifs.IResources, payloadType string) interface{} {
+	msg := &ifs.Message{}
+	_, err := msg.Unmarshal(frame, resources)
+	if err == nil {
+		return msg.View(resources, payloadType)
+	}
+	header := &frameHeader{Size: len(frame), Error: err.Error()}
+	if ifs.ValidateHeader(frame) == nil {
+		var priority ifs.Priority
+		header.Source, header.Vnet, header.Destination, header.ServiceName, header.ServiceArea, priority, _ = ifs.HeaderOf(frame)
+		header.Priority = priority.String()
+		for _, field := range []*string{&header.Source, &header.Vnet, &header.Destination} {
+			*field = strings.TrimRight(*field, "\x00")
+		}
+		header.Version = ifs.VersionOf(frame)
+	}
+	return header
+}
@@ -69,6 +69,16 @@ func UInt322Bytes(s uint32) []byte {
 	}
 }
 
+// UVarintSize returns the number of bytes binary.PutUvarint uses to encode the value
+func UVarintSize(v uint64) int {
+	size := 1
+	for v >= 0x80 {
+		v >>= 7
+		size++
+	}
+	return size
+}
+
 // Bools converts two booleans to a byte (optimized with bitwise operations)
 func Bools(request, reply bool) byte {
 	var result byte
@@ -89,6 +99,16 @@ func BoolOf(b byte) (bool, bool) {
 	return (b & 1) != 0, (b & 2) != 0
 }
 
+// BoolOfChecked converts a byte to two booleans, returning an error instead of
+// panicking when the byte has bits other than the request/reply flags set.
+func BoolOfChecked(b byte) (bool, bool, error) {
+	if b > 3 {
+		return false, false, invalid("requestReply", pRequestReply)
+	}
+	request, reply := BoolOf(b)
+	return request, reply, nil
+}
+
 func priorityMulticastModeToByte(priority Priority, multicastMode MulticastMode) byte {
 	return (byte(priority) << 4) | byte(multicastMode)
 }
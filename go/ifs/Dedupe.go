@@ -0,0 +1,142 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Dedupe.go provides receiver-side duplicate suppression of requests, so a
+// request retried after a timeout is not applied twice. Requests are keyed on
+// their client and idempotency key when they have one, or on their source and sequence.
+
+package ifs
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DedupeResult tells the receiver what to do with a request.
+type DedupeResult int
+
+const (
+	// DedupeNew means the request was not seen before and should be handled.
+	DedupeNew DedupeResult = 0
+	// DedupeInFlight means the request is still being handled and the repeat should be dropped.
+	DedupeInFlight DedupeResult = 1
+	// DedupeReplay means the request was already handled, the cached reply should be sent back.
+	DedupeReplay DedupeResult = 2
+)
+
+// DedupeWindow remembers the requests handled within the ttl, up to maxEntries,
+// and the replies sent back for them. Safe for concurrent use.
+type DedupeWindow struct {
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+	mtx        sync.Mutex
+}
+
+// dedupeEntry is a request seen by the window.
+type dedupeEntry struct {
+	key     string
+	reply   *Message
+	created time.Time
+}
+
+// NewDedupeWindow creates a dedupe window keeping requests for the ttl, up to maxEntries requests.
+func NewDedupeWindow(ttl time.Duration, maxEntries int) *DedupeWindow {
+	return &DedupeWindow{ttl: ttl, maxEntries: maxEntries, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// Begin checks a received request. For DedupeReplay, the cached reply is returned
+// addressed to the repeated request. Messages that are not requests are always DedupeNew.
+// After handling a DedupeNew request, call Complete with the reply.
+func (this *DedupeWindow) Begin(msg *Message) (DedupeResult, *Message) {
+	if !msg.request {
+		return DedupeNew, nil
+	}
+	key := dedupeKey(msg)
+	now := time.Now()
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.expire(now)
+
+	if element, ok := this.entries[key]; ok {
+		entry := element.Value.(*dedupeEntry)
+		if entry.reply == nil {
+			return DedupeInFlight, nil
+		}
+		// A retry with the same idempotency key may use a new sequence
+		reply := entry.reply.Clone()
+		reply.sequence = msg.sequence
+		reply.destination = msg.source
+		return DedupeReplay, reply
+	}
+
+	for this.maxEntries > 0 && this.order.Len() >= this.maxEntries {
+		this.remove(this.order.Front())
+	}
+	this.entries[key] = this.order.PushBack(&dedupeEntry{key: key, created: now})
+	return DedupeNew, nil
+}
+
+// Complete stores the reply of a handled request, to be replayed for repeats of it.
+func (this *DedupeWindow) Complete(msg *Message, reply *Message) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if element, ok := this.entries[dedupeKey(msg)]; ok {
+		element.Value.(*dedupeEntry).reply = reply
+	}
+}
+
+// Forget removes a request, e.g. when handling it failed and a retry should run it again.
+func (this *DedupeWindow) Forget(msg *Message) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if element, ok := this.entries[dedupeKey(msg)]; ok {
+		this.remove(element)
+	}
+}
+
+// Size returns the number of requests in the window.
+func (this *DedupeWindow) Size() int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.order.Len()
+}
+
+func (this *DedupeWindow) expire(now time.Time) {
+	for front := this.order.Front(); front != nil; front = this.order.Front() {
+		if now.Sub(front.Value.(*dedupeEntry).created) <= this.ttl {
+			return
+		}
+		this.remove(front)
+	}
+}
+
+func (this *DedupeWindow) remove(element *list.Element) {
+	delete(this.entries, element.Value.(*dedupeEntry).key)
+	this.order.Remove(element)
+}
+
+// dedupeKey identifies repeats of a request. Idempotency keys are scoped to the client,
+// its source and aaa id, and to the service, so one client never gets the reply of another.
+func dedupeKey(msg *Message) string {
+	if msg.idempotencyKey != "" {
+		return "k/" + msg.source + "/" + msg.aaaId + "/" + msg.serviceName + "/" +
+			strconv.Itoa(int(msg.serviceArea)) + "/" + msg.idempotencyKey
+	}
+	return "s/" + msg.source + "/" + strconv.FormatUint(uint64(msg.sequence), 10)
+}
@@ -30,18 +30,32 @@ type Message struct {
 	serviceArea   byte          // Target service area/partition
 	priority      Priority      // Message priority (P1-P8)
 	multicastMode MulticastMode // Delivery mode (unicast, multicast, etc.)
+	version       byte          // Wire format version (WireVersionLegacy for unversioned frames)
 
 	// Message metadata
 	action      Action           // Action type (POST, PUT, PATCH, DELETE, GET, etc.)
 	tr_state    TransactionState // Transaction state if transactional
 	aaaId       string           // Authentication/Authorization/Audit ID
 	sequence    uint32           // Message sequence number
-	timeout     uint16           // Request timeout in milliseconds
+	timeout     uint16           // Request timeout in milliseconds, see deadline for longer timeouts
 	request     bool             // True if this is a request expecting response
 	reply       bool             // True if this is a reply to a request
 	failMessage string           // Error message if delivery failed
 	data        []byte           // Payload data
 
+	// Extension fields (only carried from WireVersion4, see MessageExtensions.go)
+	metadata       map[string]string // Key/value metadata, e.g. trace context
+	compression    Compression       // Codec to compress the data with, see MessageCompression.go
+	fragmentIndex  uint32            // Index of the fragment, see MessageFragment.go
+	fragmentCount  uint32            // Number of fragments, 0 if the message is not fragmented
+	fragmentSize   uint64            // Total data size of the fragmented message
+	streamId       uint32            // Stream the message belongs to, 0 if none, see Stream.go
+	streamCredit   uint32            // Credit granted by StreamOpen and StreamAck messages
+	hopLimit       byte              // Hops the message may still take, see MessageHops.go
+	visited        []string          // VNets the message was forwarded through
+	deadline       int64             // Absolute deadline in Unix nanoseconds, 0 if none, see MessageDeadline.go
+	idempotencyKey string            // Client supplied key identifying retries of a request, see Dedupe.go
+
 	// Transaction fields (only used when tr_state != NotATransaction)
 	tr_id        string // Transaction ID
 	tr_errMsg    string // Transaction error message
@@ -118,6 +132,10 @@ func (this *Message) MulticastMode() MulticastMode {
 	return this.multicastMode
 }
 
+func (this *Message) Version() byte {
+	return this.version
+}
+
 func (this *Message) Action() Action {
 	return this.action
 }
@@ -146,6 +164,36 @@ func (this *Message) AAAId() string {
 	return this.aaaId
 }
 
+// Metadata returns the key/value metadata of the message, nil if there is none.
+func (this *Message) Metadata() map[string]string {
+	return this.metadata
+}
+
+// MetadataValue returns the metadata value for the key, empty if not set.
+func (this *Message) MetadataValue(key string) string {
+	return this.metadata[key]
+}
+
+// StreamId returns the id of the stream the message belongs to, 0 if none.
+func (this *Message) StreamId() uint32 {
+	return this.streamId
+}
+
+// StreamCredit returns the credit granted by a StreamOpen or StreamAck message.
+func (this *Message) StreamCredit() uint32 {
+	return this.streamCredit
+}
+
+// IdempotencyKey returns the client supplied key identifying retries of the request.
+func (this *Message) IdempotencyKey() string {
+	return this.idempotencyKey
+}
+
+// Compression returns the codec the data is compressed with on the wire.
+func (this *Message) Compression() Compression {
+	return this.compression
+}
+
 func (this *Message) Tr_State() TransactionState {
 	return this.tr_state
 }
@@ -221,6 +269,12 @@ func (this *Message) SetMulticastMode(multicastMode MulticastMode) {
 	this.multicastMode = multicastMode
 }
 
+// SetVersion sets the wire format version used when marshaling the message.
+// Should be the version negotiated with the peer the message is sent to.
+func (this *Message) SetVersion(version byte) {
+	this.version = version
+}
+
 func (this *Message) SetAction(action Action) {
 	this.action = action
 }
@@ -246,6 +300,40 @@ func (this *Message) SetData(data []byte) {
 	this.data = data
 }
 
+// SetMetadata sets a metadata value. Metadata is only carried from WireVersion4,
+// older wire versions drop it.
+func (this *Message) SetMetadata(key, value string) {
+	if this.metadata == nil {
+		this.metadata = make(map[string]string)
+	}
+	this.metadata[key] = value
+}
+
+// DeleteMetadata removes a metadata value.
+func (this *Message) DeleteMetadata(key string) {
+	delete(this.metadata, key)
+}
+
+// SetStream sets the stream the message belongs to and the credit it grants.
+// Stream fields are only carried from WireVersion4.
+func (this *Message) SetStream(streamId, credit uint32) {
+	this.streamId = streamId
+	this.streamCredit = credit
+}
+
+// SetIdempotencyKey sets a key identifying retries of the request, e.g. of a transactional
+// action, so a receiver applies it once even when a retry uses a new sequence.
+func (this *Message) SetIdempotencyKey(key string) {
+	this.idempotencyKey = key
+}
+
+// SetCompression sets the codec to compress the data with, usually
+// CompressionFor the negotiated wire features. The data is only compressed
+// from WireVersion4 and when it is at least CompressionMinSize bytes.
+func (this *Message) SetCompression(compression Compression) {
+	this.compression = compression
+}
+
 func (this *Message) SetTr_State(trstate TransactionState) {
 	this.tr_state = trstate
 	switch trstate {
@@ -29,6 +29,7 @@ func (this *Message) Clone() *Message {
 	clone.serviceArea = this.serviceArea
 	clone.priority = this.priority
 	clone.multicastMode = this.multicastMode
+	clone.version = this.version
 	clone.aaaId = this.aaaId
 	clone.sequence = this.sequence
 	clone.action = this.action
@@ -37,6 +38,17 @@ func (this *Message) Clone() *Message {
 	clone.request = this.request
 	clone.failMessage = this.failMessage
 	clone.data = this.data
+	clone.metadata = cloneMetadata(this.metadata)
+	clone.compression = this.compression
+	clone.streamId = this.streamId
+	clone.deadline = this.deadline
+	clone.idempotencyKey = this.idempotencyKey
+	clone.streamCredit = this.streamCredit
+	clone.hopLimit = this.hopLimit
+	clone.visited = append([]string(nil), this.visited...)
+	clone.fragmentIndex = this.fragmentIndex
+	clone.fragmentCount = this.fragmentCount
+	clone.fragmentSize = this.fragmentSize
 	clone.tr_id = this.tr_id
 	clone.tr_state = this.tr_state
 	clone.tr_errMsg = this.tr_errMsg
@@ -61,6 +73,7 @@ func (this *Message) CloneReply(localUuid, remoteUuid string) *Message {
 	clone.serviceArea = this.serviceArea
 	clone.priority = this.priority
 	clone.multicastMode = this.multicastMode
+	clone.version = this.version
 	clone.aaaId = this.aaaId
 	clone.sequence = this.sequence
 	clone.action = Reply
@@ -69,6 +82,11 @@ func (this *Message) CloneReply(localUuid, remoteUuid string) *Message {
 	clone.request = false
 	clone.failMessage = this.failMessage
 	clone.data = this.data
+	clone.metadata = cloneMetadata(this.metadata)
+	clone.compression = this.compression
+	clone.streamId = this.streamId
+	clone.deadline = this.deadline
+	clone.idempotencyKey = this.idempotencyKey
 	clone.tr_id = this.tr_id
 	clone.tr_state = this.tr_state
 	clone.tr_errMsg = this.tr_errMsg
@@ -93,6 +111,7 @@ func (this *Message) CloneFail(failMessage, remoteUuid string) *Message {
 	clone.serviceArea = this.serviceArea
 	clone.priority = this.priority
 	clone.multicastMode = this.multicastMode
+	clone.version = this.version
 	clone.aaaId = this.aaaId
 	clone.sequence = this.sequence
 	clone.action = this.action
@@ -101,6 +120,11 @@ func (this *Message) CloneFail(failMessage, remoteUuid string) *Message {
 	clone.request = false
 	clone.failMessage = failMessage
 	clone.data = this.data
+	clone.metadata = cloneMetadata(this.metadata)
+	clone.compression = this.compression
+	clone.streamId = this.streamId
+	clone.deadline = this.deadline
+	clone.idempotencyKey = this.idempotencyKey
 	clone.tr_id = this.tr_id
 	clone.tr_state = this.tr_state
 	clone.tr_errMsg = this.tr_errMsg
@@ -113,3 +137,15 @@ func (this *Message) CloneFail(failMessage, remoteUuid string) *Message {
 	clone.tr_isReplica = this.tr_isReplica
 	return clone
 }
+
+// cloneMetadata copies the metadata so a clone can change it without affecting the original.
+func cloneMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		clone[key] = value
+	}
+	return clone
+}
@@ -0,0 +1,131 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// MessageCompression.go provides payload compression for message data.
+// The data is compressed before the body is encrypted, and the codec used is
+// carried as an extension field, so compression needs WireVersion4 and a
+// codec both peers negotiated during the handshake (see FeatureCompression*).
+
+package ifs
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// Compression identifies the codec used to compress the message data.
+type Compression byte
+
+const (
+	CompressionNone  Compression = 0 // Data is not compressed
+	CompressionFlate Compression = 1 // Data is compressed with compress/flate
+	CompressionGzip  Compression = 2 // Data is compressed with compress/gzip
+)
+
+// Wire feature bits negotiated during the handshake
+const (
+	FeatureCompressionFlate uint64 = 1 << 0 // Peer can decode flate compressed data
+	FeatureCompressionGzip  uint64 = 1 << 1 // Peer can decode gzip compressed data
+	FeatureKeepalive        uint64 = 1 << 2 // Peer handles keepalive control frames, see nets.Keepalive
+)
+
+// CompressionMinSize is the smallest data size worth compressing, smaller data is sent as is.
+const CompressionMinSize = 512
+
+// MaxDecompressedSize limits the size of decompressed data, protecting against compression bombs.
+var MaxDecompressedSize = 256 * 1024 * 1024
+
+// CompressionFor returns the preferred codec of the negotiated wire features.
+func CompressionFor(features uint64) Compression {
+	if features&FeatureCompressionFlate != 0 {
+		return CompressionFlate
+	}
+	if features&FeatureCompressionGzip != 0 {
+		return CompressionGzip
+	}
+	return CompressionNone
+}
+
+// compressData compresses the message data with the message codec.
+// Returns the data as is with CompressionNone when the wire version cannot carry
+// the codec, the data is below CompressionMinSize or compressing does not make it smaller.
+func (this *Message) compressData() ([]byte, Compression) {
+	if this.compression == CompressionNone || this.version < WireVersion4 || len(this.data) < CompressionMinSize {
+		return this.data, CompressionNone
+	}
+	compressed, err := Compress(this.data, this.compression)
+	if err != nil || len(compressed) >= len(this.data) {
+		return this.data, CompressionNone
+	}
+	return compressed, this.compression
+}
+
+// Compress compresses the data with the codec.
+func Compress(data []byte, compression Compression) ([]byte, error) {
+	buff := &bytes.Buffer{}
+	var w io.WriteCloser
+	var err error
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionFlate:
+		w, err = flate.NewWriter(buff, flate.DefaultCompression)
+	case CompressionGzip:
+		w = gzip.NewWriter(buff)
+	default:
+		return nil, errors.New("unknown compression codec")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+// Decompress decompresses the data with the codec, up to MaxDecompressedSize bytes.
+func Decompress(data []byte, compression Compression) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionFlate:
+		r = flate.NewReader(bytes.NewReader(data))
+	case CompressionGzip:
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	default:
+		return nil, errors.New("unknown compression codec")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	result, err := io.ReadAll(io.LimitReader(r, int64(MaxDecompressedSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(result) > MaxDecompressedSize {
+		return nil, errors.New("decompressed data exceeds the maximum size")
+	}
+	return result, nil
+}
@@ -0,0 +1,86 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// MessageDeadline.go provides absolute message deadlines. Unlike the uint16
+// millisecond timeout, which caps a request at about 65 seconds, the deadline
+// is an absolute time carried in the deadline extension field from WireVersion4.
+// Every hop checks it (see Hop), and service handlers get it as a context deadline.
+
+package ifs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrDeadlineExceeded is returned by Hop when the message deadline passed.
+var ErrDeadlineExceeded = errors.New("message deadline exceeded")
+
+// Deadline returns the absolute deadline of the message, false if it has none.
+func (this *Message) Deadline() (time.Time, bool) {
+	if this.deadline == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, this.deadline), true
+}
+
+// SetDeadline sets the absolute deadline of the message. The millisecond timeout is
+// set to the time left as well, clamped to its range, for peers below WireVersion4.
+func (this *Message) SetDeadline(deadline time.Time) {
+	this.deadline = deadline.UnixNano()
+	left := time.Until(deadline).Milliseconds()
+	switch {
+	case left <= 0:
+		this.timeout = 1
+	case left > math.MaxUint16:
+		this.timeout = math.MaxUint16
+	default:
+		this.timeout = uint16(left)
+	}
+}
+
+// SetTimeoutDuration sets the deadline of the message to the timeout from now.
+func (this *Message) SetTimeoutDuration(timeout time.Duration) {
+	this.SetDeadline(time.Now().Add(timeout))
+}
+
+// Expired returns true if the message deadline passed.
+func (this *Message) Expired() bool {
+	return this.deadline != 0 && time.Now().UnixNano() >= this.deadline
+}
+
+// Context returns a context for handling the message, with the message deadline.
+// Messages without a deadline use the millisecond timeout, if set.
+func (this *Message) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := this.Deadline(); ok {
+		return context.WithDeadline(parent, deadline)
+	}
+	if this.timeout != 0 {
+		return context.WithTimeout(parent, time.Duration(this.timeout)*time.Millisecond)
+	}
+	return context.WithCancel(parent)
+}
+
+// checkDeadline returns ErrDeadlineExceeded if the message deadline passed.
+func (this *Message) checkDeadline(vnet string) error {
+	if this.Expired() {
+		late := time.Since(time.Unix(0, this.deadline)).Round(time.Millisecond)
+		return fmt.Errorf("%w by %s at vnet %s", ErrDeadlineExceeded, late, vnet)
+	}
+	return nil
+}
@@ -15,6 +15,8 @@ limitations under the License.
 
 package ifs
 
+import "strconv"
+
 // Priority defines message priority levels (P1 highest, P8 lowest).
 // Higher priority messages are processed before lower priority ones.
 type Priority byte
@@ -93,6 +95,12 @@ const (
 	MapR_PATCH  Action = 23 // Map-reduce PATCH phase
 	MapR_DELETE Action = 24 // Map-reduce DELETE phase
 	MapR_GET    Action = 25 // Map-reduce GET phase
+
+	// Stream Actions (incremental results with credit-based flow control, see Stream.go)
+	StreamOpen  Action = 26 // Open a stream, carries the request and the initial credit window
+	StreamData  Action = 27 // A batch of stream results, consumes one credit
+	StreamAck   Action = 28 // Grant more credit to the sender
+	StreamClose Action = 29 // Close the stream, carries the error message if it failed
 )
 
 // TransactionState represents the lifecycle state of a distributed transaction.
@@ -132,6 +140,54 @@ func (t TransactionState) String() string {
 	return "Unknown"
 }
 
+// actionNames maps actions to their string representation.
+var actionNames = map[Action]string{
+	POST: "POST", PUT: "PUT", PATCH: "PATCH", DELETE: "DELETE", GET: "GET",
+	Reply: "Reply", Notify: "Notify", Handle: "Handle", EndPoints: "EndPoints",
+	ElectionRequest: "ElectionRequest", ElectionResponse: "ElectionResponse",
+	LeaderAnnouncement: "LeaderAnnouncement", LeaderHeartbeat: "LeaderHeartbeat",
+	LeaderQuery: "LeaderQuery", LeaderResign: "LeaderResign", LeaderChallenge: "LeaderChallenge",
+	ServiceRegister: "ServiceRegister", ServiceUnregister: "ServiceUnregister", ServiceQuery: "ServiceQuery",
+	MapR_POST: "MapR_POST", MapR_PUT: "MapR_PUT", MapR_PATCH: "MapR_PATCH",
+	MapR_DELETE: "MapR_DELETE", MapR_GET: "MapR_GET",
+	StreamOpen: "StreamOpen", StreamData: "StreamData", StreamAck: "StreamAck", StreamClose: "StreamClose",
+}
+
+// String returns the string representation of an Action.
+func (a Action) String() string {
+	if name, ok := actionNames[a]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// String returns the string representation of a Priority, P1 to P8.
+func (p Priority) String() string {
+	if p > P1 {
+		return "Unknown"
+	}
+	return "P" + strconv.Itoa(int(P1-p)+1)
+}
+
+// String returns the string representation of a MulticastMode.
+func (m MulticastMode) String() string {
+	switch m {
+	case M_All:
+		return "All"
+	case M_RoundRobin:
+		return "RoundRobin"
+	case M_Proximity:
+		return "Proximity"
+	case M_Local:
+		return "Local"
+	case M_Leader:
+		return "Leader"
+	case M_Unicast:
+		return "Unicast"
+	}
+	return "Unknown"
+}
+
 const (
 	// DESTINATION_Single is a placeholder UUID for round-robin single destination.
 	DESTINATION_Single = "signleXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX"
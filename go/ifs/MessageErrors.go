@@ -0,0 +1,60 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// MessageErrors.go defines the errors returned when decoding a malformed message frame.
+// Callers can check the cause with errors.Is and the details with errors.As.
+
+package ifs
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrMessageTruncated is returned when a frame ends before a field it declares.
+	ErrMessageTruncated = errors.New("message frame is truncated")
+	// ErrMessageInvalid is returned when a field holds a value that is out of range.
+	ErrMessageInvalid = errors.New("message frame has an invalid field value")
+	// ErrMessageVersion is returned when a frame uses an unsupported wire version,
+	// or one below the version negotiated for the connection.
+	ErrMessageVersion = errors.New("message frame has an unsupported wire version")
+)
+
+// MessageDecodeError describes which field of a frame failed validation.
+type MessageDecodeError struct {
+	// Field is the name of the field that failed validation
+	Field string
+	// Offset is the position of the field within the header or decrypted body
+	Offset int
+	// Err is the cause, one of ErrMessageTruncated, ErrMessageInvalid or ErrMessageVersion
+	Err error
+}
+
+func (this *MessageDecodeError) Error() string {
+	return this.Err.Error() + ": field " + this.Field + " at offset " + strconv.Itoa(this.Offset)
+}
+
+func (this *MessageDecodeError) Unwrap() error {
+	return this.Err
+}
+
+func truncated(field string, offset int) error {
+	return &MessageDecodeError{Field: field, Offset: offset, Err: ErrMessageTruncated}
+}
+
+func invalid(field string, offset int) error {
+	return &MessageDecodeError{Field: field, Offset: offset, Err: ErrMessageInvalid}
+}
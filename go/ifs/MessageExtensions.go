@@ -0,0 +1,210 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// MessageExtensions.go provides the extension fields trailing the encrypted body
+// from WireVersion4. Each field is encoded as uvarint tag, uvarint size and value.
+// Fields with an unknown tag are skipped, so new fields can be added without
+// a new wire version.
+
+package ifs
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// Extension field tags
+const (
+	extMetadata    = 1 // Key/value metadata
+	extCompression = 2 // Codec of the compressed data
+	extFragment    = 3 // Fragment index, count and total data size
+	extStream      = 4 // Stream id and granted credit
+	extHops        = 5 // Hop limit and visited VNets
+	extDeadline    = 6 // Absolute deadline in Unix nanoseconds
+	extIdempotency = 7 // Client supplied idempotency key
+)
+
+// marshalExtensions encodes the extension fields that are set on the message.
+// The compression is the codec the marshaled data was compressed with.
+// Returns nil when there are none.
+func (this *Message) marshalExtensions(compression Compression) []byte {
+	var ext []byte
+	if compression != CompressionNone {
+		ext = appendExtension(ext, extCompression, []byte{byte(compression)})
+	}
+	if this.fragmentCount > 0 {
+		ext = appendExtension(ext, extFragment, this.marshalFragment())
+	}
+	if this.streamId != 0 {
+		value := binary.AppendUvarint(nil, uint64(this.streamId))
+		ext = appendExtension(ext, extStream, binary.AppendUvarint(value, uint64(this.streamCredit)))
+	}
+	if this.hopLimit != 0 || len(this.visited) > 0 {
+		ext = appendExtension(ext, extHops, this.marshalHops())
+	}
+	if this.deadline != 0 {
+		ext = appendExtension(ext, extDeadline, Long2Bytes(this.deadline))
+	}
+	if this.idempotencyKey != "" {
+		ext = appendExtension(ext, extIdempotency, []byte(this.idempotencyKey))
+	}
+	if len(this.metadata) > 0 {
+		ext = appendExtension(ext, extMetadata, marshalMetadata(this.metadata))
+	}
+	return ext
+}
+
+// unmarshalExtensions decodes the extension fields from the position to the end of the body.
+func (this *Message) unmarshalExtensions(body []byte, pos int) error {
+	for pos < len(body) {
+		tag, n := binary.Uvarint(body[pos:])
+		if n <= 0 {
+			return invalid("extensionTag", pos)
+		}
+		pos += n
+		size, n := binary.Uvarint(body[pos:])
+		if n <= 0 {
+			return invalid("extensionSize", pos)
+		}
+		pos += n
+		if size > uint64(len(body)-pos) {
+			return truncated("extension", pos)
+		}
+		value := body[pos : pos+int(size)]
+		switch tag {
+		case extMetadata:
+			metadata, err := unmarshalMetadata(value, pos)
+			if err != nil {
+				return err
+			}
+			this.metadata = metadata
+		case extCompression:
+			if size != 1 {
+				return invalid("compression", pos)
+			}
+			this.compression = Compression(value[0])
+		case extFragment:
+			if err := this.unmarshalFragment(value, pos); err != nil {
+				return err
+			}
+		case extStream:
+			if err := this.unmarshalStream(value, pos); err != nil {
+				return err
+			}
+		case extHops:
+			if err := this.unmarshalHops(value, pos); err != nil {
+				return err
+			}
+		case extDeadline:
+			if size != 8 {
+				return invalid("deadline", pos)
+			}
+			this.deadline = Bytes2Long(value)
+		case extIdempotency:
+			this.idempotencyKey = string(value)
+		}
+		pos += int(size)
+	}
+	return nil
+}
+
+// unmarshalStream decodes the stream extension field value.
+func (this *Message) unmarshalStream(data []byte, offset int) error {
+	id, n := binary.Uvarint(data)
+	if n <= 0 || id == 0 || id > 1<<32-1 {
+		return invalid("streamId", offset)
+	}
+	credit, m := binary.Uvarint(data[n:])
+	if m <= 0 || n+m != len(data) || credit > 1<<32-1 {
+		return invalid("streamCredit", offset+n)
+	}
+	this.streamId = uint32(id)
+	this.streamCredit = uint32(credit)
+	return nil
+}
+
+// appendExtension appends a single extension field.
+func appendExtension(ext []byte, tag uint64, value []byte) []byte {
+	ext = binary.AppendUvarint(ext, tag)
+	ext = binary.AppendUvarint(ext, uint64(len(value)))
+	return append(ext, value...)
+}
+
+// appendText appends a uvarint size prefixed string.
+func appendText(data []byte, text string) []byte {
+	data = binary.AppendUvarint(data, uint64(len(text)))
+	return append(data, text...)
+}
+
+// readText reads a uvarint size prefixed string at the position.
+// Returns the string and the position after it. The offset is used for error reporting.
+func readText(data []byte, pos, offset int) (string, int, error) {
+	size, n := binary.Uvarint(data[pos:])
+	if n <= 0 {
+		return "", 0, invalid("textSize", offset+pos)
+	}
+	pos += n
+	if size > uint64(len(data)-pos) {
+		return "", 0, truncated("text", offset+pos)
+	}
+	return string(data[pos : pos+int(size)]), pos + int(size), nil
+}
+
+// marshalMetadata encodes the metadata as a uvarint count followed by the key/value pairs.
+// Keys are sorted so the same metadata always produces the same bytes.
+func marshalMetadata(metadata map[string]string) []byte {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	data := binary.AppendUvarint(nil, uint64(len(keys)))
+	for _, key := range keys {
+		data = appendText(data, key)
+		data = appendText(data, metadata[key])
+	}
+	return data
+}
+
+// unmarshalMetadata decodes metadata encoded by marshalMetadata.
+func unmarshalMetadata(data []byte, offset int) (map[string]string, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, invalid("metadataCount", offset)
+	}
+	// Every pair takes at least two bytes, which bounds the map allocation
+	if count > uint64(len(data)-n)/2 {
+		return nil, truncated("metadata", offset)
+	}
+	metadata := make(map[string]string, int(count))
+	pos := n
+	for i := uint64(0); i < count; i++ {
+		var key, value string
+		var err error
+		key, pos, err = readText(data, pos, offset)
+		if err != nil {
+			return nil, err
+		}
+		value, pos, err = readText(data, pos, offset)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = value
+	}
+	if pos != len(data) {
+		return nil, invalid("metadata", offset+pos)
+	}
+	return metadata, nil
+}
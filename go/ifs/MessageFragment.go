@@ -0,0 +1,250 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// MessageFragment.go provides fragmentation of messages whose data is too large
+// for a single frame, and their reassembly on the receiving side.
+// Fragments are clones of the original message, each carrying a chunk of the data
+// and a fragment extension field with the chunk index, the number of fragments and
+// the total data size. Fragments of the same message share its source and sequence.
+
+package ifs
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrFragmentInvalid is returned for fragments that do not match the message they belong to.
+	ErrFragmentInvalid = errors.New("invalid message fragment")
+	// ErrFragmentLimit is returned when a message would exceed the reassembly memory limit.
+	ErrFragmentLimit = errors.New("message reassembly memory limit exceeded")
+)
+
+// Fragment splits the message into fragments carrying at most chunkSize bytes of data each.
+// A message whose data fits in a single chunk is returned as is.
+// Fragments need WireVersion4 to carry the fragment extension field.
+func (this *Message) Fragment(chunkSize int) ([]*Message, error) {
+	if chunkSize <= 0 {
+		return nil, errors.New("invalid fragment chunk size")
+	}
+	if len(this.data) <= chunkSize {
+		return []*Message{this}, nil
+	}
+	if this.version < WireVersion4 {
+		return nil, errors.New("message fragmentation requires wire version 4")
+	}
+	count := (len(this.data) + chunkSize - 1) / chunkSize
+	fragments := make([]*Message, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(this.data) {
+			end = len(this.data)
+		}
+		fragment := this.Clone()
+		fragment.data = this.data[i*chunkSize : end]
+		fragment.fragmentIndex = uint32(i)
+		fragment.fragmentCount = uint32(count)
+		fragment.fragmentSize = uint64(len(this.data))
+		fragments[i] = fragment
+	}
+	return fragments, nil
+}
+
+// IsFragment returns true if the message is a fragment of a larger message.
+func (this *Message) IsFragment() bool {
+	return this.fragmentCount > 0
+}
+
+// FragmentIndex returns the index of the fragment, starting from 0.
+func (this *Message) FragmentIndex() uint32 {
+	return this.fragmentIndex
+}
+
+// FragmentCount returns the number of fragments of the message, 0 if it is not fragmented.
+func (this *Message) FragmentCount() uint32 {
+	return this.fragmentCount
+}
+
+// FragmentSize returns the total data size of the fragmented message.
+func (this *Message) FragmentSize() uint64 {
+	return this.fragmentSize
+}
+
+// marshalFragment encodes the fragment extension field value.
+func (this *Message) marshalFragment() []byte {
+	data := binary.AppendUvarint(nil, uint64(this.fragmentIndex))
+	data = binary.AppendUvarint(data, uint64(this.fragmentCount))
+	return binary.AppendUvarint(data, this.fragmentSize)
+}
+
+// unmarshalFragment decodes the fragment extension field value.
+func (this *Message) unmarshalFragment(data []byte, offset int) error {
+	var values [3]uint64
+	pos := 0
+	for i := range values {
+		value, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return invalid("fragment", offset+pos)
+		}
+		values[i] = value
+		pos += n
+	}
+	if pos != len(data) || values[1] == 0 || values[1] > values[2] || values[0] >= values[1] || values[1] > 1<<32-1 {
+		return invalid("fragment", offset)
+	}
+	this.fragmentIndex = uint32(values[0])
+	this.fragmentCount = uint32(values[1])
+	this.fragmentSize = values[2]
+	return nil
+}
+
+// Reassembler collects fragments and rebuilds the original messages.
+// Partially received messages are dropped when they are not completed within
+// the timeout, and the total data size of partially received messages is
+// limited to maxMemory bytes. Safe for concurrent use.
+type Reassembler struct {
+	timeout   time.Duration
+	maxMemory uint64
+	used      uint64
+	pending   map[string]*partialMessage
+	mtx       sync.Mutex
+}
+
+// partialMessage is a message whose fragments are being received.
+type partialMessage struct {
+	first     *Message
+	fragments map[uint32][]byte
+	count     uint32
+	size      uint64
+	received  uint64
+	created   time.Time
+}
+
+// NewReassembler creates a reassembler with the timeout and memory limit for partially received messages.
+func NewReassembler(timeout time.Duration, maxMemory uint64) *Reassembler {
+	return &Reassembler{timeout: timeout, maxMemory: maxMemory, pending: make(map[string]*partialMessage)}
+}
+
+// Add adds a received message. Messages that are not fragments are returned as is.
+// For fragments, the reassembled message is returned once all of its fragments
+// were received, and nil until then. Duplicate fragments are ignored.
+// A fragment that does not match the message it belongs to drops the whole message.
+func (this *Reassembler) Add(msg *Message) (*Message, error) {
+	if !msg.IsFragment() {
+		return msg, nil
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	now := time.Now()
+	this.expire(now)
+
+	key := fragmentKey(msg)
+	partial, ok := this.pending[key]
+	if !ok {
+		if msg.fragmentSize > this.maxMemory-this.used {
+			return nil, ErrFragmentLimit
+		}
+		// The full size is reserved up front, so the limit holds however the fragments arrive
+		partial = &partialMessage{
+			fragments: make(map[uint32][]byte),
+			count:     msg.fragmentCount,
+			size:      msg.fragmentSize,
+			created:   now,
+		}
+		this.pending[key] = partial
+		this.used += partial.size
+	}
+	if msg.fragmentCount != partial.count || msg.fragmentSize != partial.size ||
+		msg.fragmentIndex >= partial.count || uint64(len(msg.data)) > partial.size-partial.received {
+		this.drop(key)
+		return nil, ErrFragmentInvalid
+	}
+	if _, ok = partial.fragments[msg.fragmentIndex]; ok {
+		return nil, nil
+	}
+	partial.fragments[msg.fragmentIndex] = msg.data
+	partial.received += uint64(len(msg.data))
+	if msg.fragmentIndex == 0 {
+		partial.first = msg
+	}
+	if uint32(len(partial.fragments)) < partial.count {
+		return nil, nil
+	}
+
+	this.drop(key)
+	if partial.received != partial.size {
+		return nil, ErrFragmentInvalid
+	}
+	data := make([]byte, 0, partial.size)
+	for i := uint32(0); i < partial.count; i++ {
+		data = append(data, partial.fragments[i]...)
+	}
+	result := partial.first.Clone()
+	result.data = data
+	result.fragmentIndex = 0
+	result.fragmentCount = 0
+	result.fragmentSize = 0
+	return result, nil
+}
+
+// Expire drops partially received messages older than the timeout.
+// Returns the number of dropped messages.
+func (this *Reassembler) Expire() int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.expire(time.Now())
+}
+
+// Pending returns the number of partially received messages.
+func (this *Reassembler) Pending() int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return len(this.pending)
+}
+
+// MemoryUsed returns the memory reserved by partially received messages.
+func (this *Reassembler) MemoryUsed() uint64 {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.used
+}
+
+func (this *Reassembler) expire(now time.Time) int {
+	dropped := 0
+	for key, partial := range this.pending {
+		if now.Sub(partial.created) > this.timeout {
+			this.drop(key)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+func (this *Reassembler) drop(key string) {
+	partial, ok := this.pending[key]
+	if ok {
+		this.used -= partial.size
+		delete(this.pending, key)
+	}
+}
+
+// fragmentKey identifies the fragments of the same message.
+func fragmentKey(msg *Message) string {
+	return msg.source + "/" + strconv.FormatUint(uint64(msg.sequence), 10)
+}
@@ -0,0 +1,113 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// MessageHops.go provides the hop limit and the path of visited VNets of
+// forwarded messages, so a misconfigured route table cannot bounce a message
+// forever. Both are carried in the hops extension field from WireVersion4.
+
+package ifs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// DefaultHopLimit is the hop limit of a message that did not set one.
+const DefaultHopLimit byte = 16
+
+var (
+	// ErrHopLimitExceeded is returned by Hop when the message used up its hop limit.
+	ErrHopLimitExceeded = errors.New("message hop limit exceeded")
+	// ErrRoutingLoop is returned by Hop when the message already passed through the VNet.
+	ErrRoutingLoop = errors.New("message routing loop detected")
+)
+
+// HopLimit returns the number of hops the message may still take.
+func (this *Message) HopLimit() byte {
+	return this.hopLimit
+}
+
+// SetHopLimit sets the number of hops the message may take, 0 uses DefaultHopLimit.
+func (this *Message) SetHopLimit(hopLimit byte) {
+	this.hopLimit = hopLimit
+}
+
+// Visited returns the VNets the message was forwarded through, in order.
+func (this *Message) Visited() []string {
+	return this.visited
+}
+
+// Hop records that the message is forwarded through the VNet, decrementing the hop limit.
+// Returns ErrHopLimitExceeded, ErrRoutingLoop or ErrDeadlineExceeded when the message must
+// not be forwarded, the caller should then drop it and send back this.CloneFail(err.Error(), vnet).
+func (this *Message) Hop(vnet string) error {
+	if err := this.checkDeadline(vnet); err != nil {
+		return err
+	}
+	for _, visited := range this.visited {
+		if visited == vnet {
+			return fmt.Errorf("%w, already visited vnet %s after %d hops", ErrRoutingLoop, vnet, len(this.visited))
+		}
+	}
+	if this.hopLimit == 0 && len(this.visited) == 0 {
+		this.hopLimit = DefaultHopLimit
+	}
+	if this.hopLimit == 0 {
+		return fmt.Errorf("%w after %d hops at vnet %s", ErrHopLimitExceeded, len(this.visited), vnet)
+	}
+	this.hopLimit--
+	this.visited = append(this.visited, vnet)
+	return nil
+}
+
+// marshalHops encodes the hops extension field value, the hop limit followed by the visited VNets.
+func (this *Message) marshalHops() []byte {
+	data := []byte{this.hopLimit}
+	data = binary.AppendUvarint(data, uint64(len(this.visited)))
+	for _, vnet := range this.visited {
+		data = appendText(data, vnet)
+	}
+	return data
+}
+
+// unmarshalHops decodes the hops extension field value.
+func (this *Message) unmarshalHops(data []byte, offset int) error {
+	if len(data) < 2 {
+		return truncated("hops", offset)
+	}
+	count, n := binary.Uvarint(data[1:])
+	// A message cannot visit more VNets than the largest hop limit allows
+	if n <= 0 || count > uint64(^byte(0)) {
+		return invalid("hopsCount", offset+1)
+	}
+	pos := 1 + n
+	visited := make([]string, 0, int(count))
+	for i := uint64(0); i < count; i++ {
+		var vnet string
+		var err error
+		vnet, pos, err = readText(data, pos, offset)
+		if err != nil {
+			return err
+		}
+		visited = append(visited, vnet)
+	}
+	if pos != len(data) {
+		return invalid("hops", offset+pos)
+	}
+	this.hopLimit = data[0]
+	this.visited = visited
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// MessageJSON.go provides a human-readable JSON representation of a message,
+// used for logging and for inspecting captured frames.
+
+package ifs
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// MessageView is the JSON representation of a message.
+type MessageView struct {
+	Source         string            `json:"source"`
+	Vnet           string            `json:"vnet"`
+	Destination    string            `json:"destination,omitempty"`
+	ServiceName    string            `json:"serviceName"`
+	ServiceArea    byte              `json:"serviceArea"`
+	Priority       string            `json:"priority"`
+	MulticastMode  string            `json:"multicastMode"`
+	Version        byte              `json:"version"`
+	Action         string            `json:"action"`
+	AaaId          string            `json:"aaaId,omitempty"`
+	Sequence       uint32            `json:"sequence"`
+	Timeout        uint16            `json:"timeout,omitempty"`
+	Request        bool              `json:"request"`
+	Reply          bool              `json:"reply"`
+	FailMessage    string            `json:"failMessage,omitempty"`
+	DataSize       int               `json:"dataSize"`
+	Data           []byte            `json:"data,omitempty"`
+	PayloadType    string            `json:"payloadType,omitempty"`
+	Payload        json.RawMessage   `json:"payload,omitempty"`
+	PayloadError   string            `json:"payloadError,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Compression    Compression       `json:"compression,omitempty"`
+	Fragment       []uint64          `json:"fragment,omitempty"`
+	StreamId       uint32            `json:"streamId,omitempty"`
+	StreamCredit   uint32            `json:"streamCredit,omitempty"`
+	HopLimit       byte              `json:"hopLimit,omitempty"`
+	Visited        []string          `json:"visited,omitempty"`
+	Deadline       *time.Time        `json:"deadline,omitempty"`
+	IdempotencyKey string            `json:"idempotencyKey,omitempty"`
+	Transaction    *TransactionView  `json:"transaction,omitempty"`
+}
+
+// TransactionView is the JSON representation of the transaction fields of a message.
+type TransactionView struct {
+	Id        string `json:"id"`
+	State     string `json:"state"`
+	ErrMsg    string `json:"errMsg,omitempty"`
+	Created   int64  `json:"created,omitempty"`
+	Queued    int64  `json:"queued,omitempty"`
+	Running   int64  `json:"running,omitempty"`
+	End       int64  `json:"end,omitempty"`
+	Timeout   int64  `json:"timeout,omitempty"`
+	Replica   byte   `json:"replica,omitempty"`
+	IsReplica bool   `json:"isReplica,omitempty"`
+}
+
+// View returns the JSON representation of the message. When the payload type is given,
+// the data is decoded as that type through the resources registry, or as a registered
+// protobuf message when there is no registry, otherwise the raw data is kept.
+func (this *Message) View(resources IResources, payloadType string) *MessageView {
+	view := &MessageView{
+		Source:         trimPadding(this.source),
+		Vnet:           trimPadding(this.vnet),
+		Destination:    trimPadding(this.destination),
+		ServiceName:    this.serviceName,
+		ServiceArea:    this.serviceArea,
+		Priority:       this.priority.String(),
+		MulticastMode:  this.multicastMode.String(),
+		Version:        this.version,
+		Action:         this.action.String(),
+		AaaId:          trimPadding(this.aaaId),
+		Sequence:       this.sequence,
+		Timeout:        this.timeout,
+		Request:        this.request,
+		Reply:          this.reply,
+		FailMessage:    this.failMessage,
+		DataSize:       len(this.data),
+		Metadata:       this.metadata,
+		Compression:    this.compression,
+		StreamId:       this.streamId,
+		StreamCredit:   this.streamCredit,
+		HopLimit:       this.hopLimit,
+		Visited:        this.visited,
+		IdempotencyKey: this.idempotencyKey,
+	}
+	if this.fragmentCount > 0 {
+		view.Fragment = []uint64{uint64(this.fragmentIndex), uint64(this.fragmentCount), this.fragmentSize}
+	}
+	if deadline, ok := this.Deadline(); ok {
+		view.Deadline = &deadline
+	}
+	if this.tr_state != NotATransaction {
+		view.Transaction = &TransactionView{
+			Id:        trimPadding(this.tr_id),
+			State:     this.tr_state.String(),
+			ErrMsg:    this.tr_errMsg,
+			Created:   this.tr_created,
+			Queued:    this.tr_queued,
+			Running:   this.tr_running,
+			End:       this.tr_end,
+			Timeout:   this.tr_timeout,
+			Replica:   this.tr_replica,
+			IsReplica: this.tr_isReplica,
+		}
+	}
+	if payloadType == "" {
+		view.Data = this.data
+		return view
+	}
+	view.PayloadType = payloadType
+	payload, err := decodePayload(this.data, payloadType, resources)
+	if err != nil {
+		view.PayloadError = err.Error()
+		view.Data = this.data
+		return view
+	}
+	view.Payload = payload
+	return view
+}
+
+// ToJSON returns the indented JSON representation of the message, see View.
+func (this *Message) ToJSON(resources IResources, payloadType string) ([]byte, error) {
+	return json.MarshalIndent(this.View(resources, payloadType), "", "  ")
+}
+
+// MarshalJSON implements json.Marshaler with the raw data kept undecoded.
+func (this *Message) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.View(nil, ""))
+}
+
+// String returns the single line JSON representation of the message, without the data.
+func (this *Message) String() string {
+	view := this.View(nil, "")
+	view.Data = nil
+	data, err := json.Marshal(view)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// trimPadding removes the zero padding of fixed size fields, e.g. UUIDs shorter than 36 bytes.
+func trimPadding(value string) string {
+	return strings.TrimRight(value, "\x00")
+}
+
+// decodePayload decodes the data as the payload type and returns it as JSON.
+func decodePayload(data []byte, payloadType string, resources IResources) (json.RawMessage, error) {
+	var payload interface{}
+	var registry IRegistry
+	if resources != nil {
+		registry = resources.Registry()
+	}
+	if registry != nil {
+		info, err := registry.Info(payloadType)
+		if err != nil {
+			return nil, err
+		}
+		serializer := info.Serializer(BINARY)
+		if serializer == nil {
+			return nil, errors.New("no binary serializer for " + payloadType)
+		}
+		if payload, err = serializer.Unmarshal(data, resources); err != nil {
+			return nil, err
+		}
+	} else {
+		messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(payloadType))
+		if err != nil {
+			return nil, err
+		}
+		pb := messageType.New().Interface()
+		if err = proto.Unmarshal(data, pb); err != nil {
+			return nil, err
+		}
+		payload = pb
+	}
+	if pb, ok := payload.(proto.Message); ok {
+		return protojson.Marshal(pb)
+	}
+	return json.Marshal(payload)
+}
@@ -16,9 +16,22 @@ limitations under the License.
 // MessageMarshal.go provides message serialization to bytes.
 // The message format consists of a header (routing info, unencrypted) and
 // a body (action, data, transaction info, encrypted).
+// Versioned frames carry a version marker byte right after the routing header,
+// so routing offsets stay identical across all wire versions.
+// From WireVersion2 the body is encrypted with authenticated encryption and the
+// header, including the version marker, is bound to it as associated data.
+// From WireVersion3 the header is extended with the full service name and the
+// body text fields use varint sizes, removing the fixed length limits.
+// From WireVersion4 the body is followed by extension fields (see MessageExtensions.go),
+// and the data may be compressed (see MessageCompression.go).
 
 package ifs
 
+import (
+	"encoding/binary"
+	"errors"
+)
+
 // Message wire format constants - define byte positions in serialized messages
 const (
 	sUuid        = 36
@@ -42,21 +55,62 @@ const (
 	pRequestReply    = pTimeout + sUint16
 	pFailMessageSize = pRequestReply + sByte
 	pFailMessage     = pFailMessageSize + sByte
+
+	// PVersion is the position of the version marker in versioned frames
+	PVersion = PPriority + sByte
+	// pExtServiceName is the position of the varint-prefixed service name from WireVersion3
+	pExtServiceName = PVersion + sByte
+
+	// maxTextSize is the longest text field before WireVersion3
+	maxTextSize = 255
+)
+
+// Message wire format versions.
+const (
+	// WireVersionLegacy is the original, unversioned frame layout.
+	WireVersionLegacy byte = 0
+	// WireVersion1 adds the version marker byte after the routing header.
+	WireVersion1 byte = 1
+	// WireVersion2 encrypts the body with AEAD, authenticating the header as associated data.
+	WireVersion2 byte = 2
+	// WireVersion3 adds the full, varint-prefixed service name after the version marker
+	// and varint sizes for the fail message and transaction error message.
+	WireVersion3 byte = 3
+	// WireVersion4 adds extension fields, such as metadata, after the end of the body.
+	WireVersion4 byte = 4
+	// WireVersionMin is the lowest wire version this node can decode.
+	WireVersionMin = WireVersionLegacy
+	// WireVersionMax is the highest wire version this node can encode and decode.
+	WireVersionMax = WireVersion4
+
+	// wireVersionMark flags the version byte. Legacy frames start their body with
+	// the security provider's text encoding, which never has the high bit set.
+	wireVersionMark byte = 0x80
 )
 
 // Marshal serializes the message to bytes for network transmission.
 // The header (source, vnet, destination, service, area, priority) is unencrypted.
 // The body (action, AAA ID, sequence, timeout, data, transaction info) is encrypted.
+// Before WireVersion3 the service name is limited to 10 bytes and the text fields to 255 bytes,
+// longer values are truncated so the frame stays valid.
 func (this *Message) Marshal(any interface{}, resources IResources) ([]byte, error) {
-	failMessageSize := len(this.failMessage)
-	dataSize := len(this.data)
-	trErrMsgSize := len(this.tr_errMsg)
+	failMessage := this.failMessage
+	trErrMsg := this.tr_errMsg
+	if this.version < WireVersion3 {
+		failMessage = truncateText(failMessage)
+		trErrMsg = truncateText(trErrMsg)
+	}
+	data, compression := this.compressData()
+	failMessageSize := len(failMessage)
+	dataSize := len(data)
+	trErrMsgSize := len(trErrMsg)
 
-	pDataSize := pFailMessage + failMessageSize
+	pFailMsg := pFailMessageSize + textSizeLen(this.version, failMessageSize)
+	pDataSize := pFailMsg + failMessageSize
 	pData := pDataSize + sUint32
 	pTrId := pData + dataSize
 	pTrErrMsgSize := pTrId + sUuid
-	pTrErrMsg := pTrErrMsgSize + sByte
+	pTrErrMsg := pTrErrMsgSize + textSizeLen(this.version, trErrMsgSize)
 	pTrCreated := pTrErrMsg + trErrMsgSize
 	pTrQueued := pTrCreated + 8
 	pTrRunning := pTrQueued + 8
@@ -72,34 +126,53 @@ func (this *Message) Marshal(any interface{}, resources IResources) ([]byte, err
 	} else {
 		bodySize = pEnd
 	}
+	var extensions []byte
+	if this.version >= WireVersion4 {
+		extensions = this.marshalExtensions(compression)
+	}
+	pExtensions := bodySize
+	bodySize += len(extensions)
 
-	totalSize := PPriority + sByte + bodySize
-	result := make([]byte, totalSize)
+	headerSize := PVersion
+	if this.version != WireVersionLegacy {
+		headerSize++
+	}
+	if this.version >= WireVersion3 {
+		headerSize += textSizeLen(this.version, len(this.serviceName)) + len(this.serviceName)
+	}
 
-	header := result[:PPriority+sByte]
+	header := make([]byte, headerSize)
 	copy(header[pSource:pVnet], this.source)
 	copy(header[pVnet:pDestination], this.vnet)
 	copy(header[pDestination:pServiceName], this.destination)
 	copy(header[pServiceName:pServiceArea], this.serviceName)
 	header[pServiceArea] = this.serviceArea
 	header[PPriority] = priorityMulticastModeToByte(this.priority, this.multicastMode)
+	if this.version != WireVersionLegacy {
+		header[PVersion] = wireVersionMark | this.version
+	}
+	if this.version >= WireVersion3 {
+		// The full service name follows the version marker, the fixed field keeps its prefix
+		n := putTextSize(header[pExtServiceName:], this.version, len(this.serviceName))
+		copy(header[pExtServiceName+n:], this.serviceName)
+	}
 
-	body := result[PPriority+sByte:]
+	body := make([]byte, bodySize)
 	body[pAction] = byte(this.action)
 	body[pTrState] = byte(this.tr_state)
 	copy(body[pAaaId:pSequence], this.aaaId)
 	copy(body[pSequence:pTimeout], UInt322Bytes(this.sequence))
 	copy(body[pTimeout:pRequestReply], UInt162Bytes(this.timeout))
 	body[pRequestReply] = Bools(this.request, this.reply)
-	body[pFailMessageSize] = byte(failMessageSize)
-	copy(body[pFailMessage:pDataSize], this.failMessage)
+	putTextSize(body[pFailMessageSize:], this.version, failMessageSize)
+	copy(body[pFailMsg:pDataSize], failMessage)
 	copy(body[pDataSize:pData], UInt322Bytes(uint32(dataSize)))
-	copy(body[pData:pTrId], this.data)
+	copy(body[pData:pTrId], data)
 
 	if this.tr_state != NotATransaction {
 		copy(body[pTrId:pTrErrMsgSize], this.tr_id)
-		body[pTrErrMsgSize] = byte(trErrMsgSize)
-		copy(body[pTrErrMsg:pTrCreated], this.tr_errMsg)
+		putTextSize(body[pTrErrMsgSize:], this.version, trErrMsgSize)
+		copy(body[pTrErrMsg:pTrCreated], trErrMsg)
 		copy(body[pTrCreated:pTrQueued], Long2Bytes(this.tr_created))
 		copy(body[pTrQueued:pTrRunning], Long2Bytes(this.tr_queued))
 		copy(body[pTrRunning:pTrEnd], Long2Bytes(this.tr_running))
@@ -112,16 +185,54 @@ func (this *Message) Marshal(any interface{}, resources IResources) ([]byte, err
 			body[pTrIsReplica] = 0
 		}
 	}
+	copy(body[pExtensions:], extensions)
 
-	bodyEnc, err := resources.Security().Encrypt(body)
+	bodyEnc, err := encryptBody(this.version, header, body, resources.Security())
 	if err != nil {
 		return nil, err
 	}
 
-	headerSize := PPriority + sByte
 	finalData := make([]byte, headerSize+len(bodyEnc))
 	copy(finalData[:headerSize], header)
 	copy(finalData[headerSize:], bodyEnc)
 
 	return finalData, nil
 }
+
+// truncateText limits a text field to the 255 bytes a single byte size can describe.
+func truncateText(text string) string {
+	if len(text) > maxTextSize {
+		return text[:maxTextSize]
+	}
+	return text
+}
+
+// textSizeLen returns the number of bytes used to encode a text size for the wire version.
+func textSizeLen(version byte, size int) int {
+	if version < WireVersion3 {
+		return sByte
+	}
+	return UVarintSize(uint64(size))
+}
+
+// putTextSize encodes a text size for the wire version and returns the number of bytes written.
+func putTextSize(data []byte, version byte, size int) int {
+	if version < WireVersion3 {
+		data[0] = byte(size)
+		return sByte
+	}
+	return binary.PutUvarint(data, uint64(size))
+}
+
+// encryptBody encrypts the message body according to the wire version.
+// WireVersion2 and above bind the header to the body using authenticated encryption.
+func encryptBody(version byte, header, body []byte, security ISecurityProvider) (string, error) {
+	if version < WireVersion2 {
+		return security.Encrypt(body)
+	}
+	aead, ok := security.(ISecurityProviderAEAD)
+	if !ok {
+		return "", errors.New("security provider does not support authenticated encryption")
+	}
+	return aead.EncryptAEAD(body, header)
+}
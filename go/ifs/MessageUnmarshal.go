@@ -19,6 +19,8 @@ limitations under the License.
 package ifs
 
 import (
+	"encoding/binary"
+	"errors"
 	"unsafe"
 )
 
@@ -30,8 +32,21 @@ func unsafeString(b []byte) string {
 
 // Unmarshal deserializes a message from bytes received over the network.
 // Decrypts the body using the security provider and populates all fields.
+// Every length field is validated, a malformed frame returns a *MessageDecodeError.
+// Frames of any supported version are accepted, use UnmarshalMinVersion, or nets.UnmarshalMessage,
+// for frames read from a connection that negotiated a wire version.
 // Note: Uses string() instead of unsafeString to copy data and allow GC of original buffers.
 func (this *Message) Unmarshal(data []byte, resources IResources) (interface{}, error) {
+	return this.UnmarshalMinVersion(data, resources, WireVersionLegacy)
+}
+
+// UnmarshalMinVersion is Unmarshal rejecting frames below the minimum wire version.
+// Pass the WireVersion negotiated for the connection, so a frame stripped of its
+// version marker on the path is not accepted without authenticated encryption.
+func (this *Message) UnmarshalMinVersion(data []byte, resources IResources, minVersion byte) (interface{}, error) {
+	if len(data) < PVersion {
+		return nil, truncated("header", len(data))
+	}
 
 	this.source = string(data[pSource:pVnet])
 	this.vnet = string(data[pVnet:pDestination])
@@ -39,41 +54,88 @@ func (this *Message) Unmarshal(data []byte, resources IResources) (interface{},
 	this.serviceName = toServiceNameSafe(data)
 	this.serviceArea = data[pServiceArea]
 	this.priority, this.multicastMode = ByteToPriorityMulticastMode(data[PPriority])
+	this.version = VersionOf(data)
+	if this.version > WireVersionMax || this.version < minVersion {
+		return nil, &MessageDecodeError{Field: "version", Offset: PVersion, Err: ErrMessageVersion}
+	}
 
-	body, err := resources.Security().Decrypt(string(data[PPriority+1:]))
+	bodyStart, err := headerSizeOf(data, this.version)
 	if err != nil {
 		return nil, err
 	}
+	body, err := decryptBody(this.version, data[:bodyStart], data[bodyStart:], resources.Security())
+	if err != nil {
+		return nil, err
+	}
+	return nil, this.unmarshalBody(body)
+}
+
+// unmarshalBody populates the body fields from the decrypted body,
+// checking each position against the body length before reading it.
+func (this *Message) unmarshalBody(body []byte) error {
+	if len(body) < pFailMessage {
+		return truncated("body", 0)
+	}
 
 	this.action = Action(body[pAction])
 	this.tr_state = TransactionState(body[pTrState])
 	this.aaaId = string(body[pAaaId:pSequence])
 	this.sequence = Bytes2UInt32(body[pSequence:pTimeout])
 	this.timeout = Bytes2UInt16(body[pTimeout:pRequestReply])
-	this.request, this.reply = BoolOf(body[pRequestReply])
-
-	failMessageSize := int(body[pFailMessageSize])
-	pDataSize := pFailMessage + failMessageSize
-	this.failMessage = string(body[pFailMessage:pDataSize])
+	var err error
+	this.request, this.reply, err = BoolOfChecked(body[pRequestReply])
+	if err != nil {
+		return err
+	}
 
+	failMessageSize, n, err := textSize(body, pFailMessageSize, this.version)
+	if err != nil {
+		return err
+	}
+	pFailMsg := pFailMessageSize + n
+	if failMessageSize > uint64(len(body)-pFailMsg) {
+		return truncated("failMessage", pFailMsg)
+	}
+	pDataSize := pFailMsg + int(failMessageSize)
 	pData := pDataSize + sUint32
-	dataSize := int(Bytes2UInt32(body[pDataSize:pData]))
-	pTrId := pData + dataSize
+	if len(body) < pData {
+		return truncated("dataSize", pDataSize)
+	}
+	this.failMessage = string(body[pFailMsg:pDataSize])
+
+	dataSize := uint64(Bytes2UInt32(body[pDataSize:pData]))
+	if dataSize > uint64(len(body)-pData) {
+		return truncated("data", pData)
+	}
+	pTrId := pData + int(dataSize)
 	// Copy data slice to allow GC of the decrypted body buffer
 	this.data = append([]byte(nil), body[pData:pTrId]...)
+	pEnd := pTrId
 
 	if this.tr_state != NotATransaction {
 		pTrErrMsgSize := pTrId + sUuid
+		if len(body) <= pTrErrMsgSize {
+			return truncated("trId", pTrId)
+		}
 		this.tr_id = string(body[pTrId:pTrErrMsgSize])
-		trErrMsgSize := int(body[pTrErrMsgSize])
-		pTrErrMsg := pTrErrMsgSize + sByte
-		pTrCreated := pTrErrMsg + trErrMsgSize
+		trErrMsgSize, n, err := textSize(body, pTrErrMsgSize, this.version)
+		if err != nil {
+			return err
+		}
+		pTrErrMsg := pTrErrMsgSize + n
+		if trErrMsgSize > uint64(len(body)-pTrErrMsg) {
+			return truncated("trErrMsg", pTrErrMsg)
+		}
+		pTrCreated := pTrErrMsg + int(trErrMsgSize)
 		pTrQueued := pTrCreated + 8
 		pTrRunning := pTrQueued + 8
 		pTrEnd := pTrRunning + 8
 		pTrTimeout := pTrEnd + 8
 		pTrReplica := pTrTimeout + 8
 		pTrIsReplica := pTrReplica + sByte
+		if len(body) <= pTrIsReplica {
+			return truncated("trTimes", pTrCreated)
+		}
 		this.tr_errMsg = string(body[pTrErrMsg:pTrCreated])
 		this.tr_created = Bytes2Long(body[pTrCreated:pTrQueued])
 		this.tr_queued = Bytes2Long(body[pTrQueued:pTrRunning])
@@ -82,14 +144,107 @@ func (this *Message) Unmarshal(data []byte, resources IResources) (interface{},
 		this.tr_timeout = Bytes2Long(body[pTrTimeout:pTrReplica])
 		this.tr_replica = body[pTrReplica]
 		this.tr_isReplica = body[pTrIsReplica] == 1
+		pEnd = pTrIsReplica + sByte
 	}
 
-	return nil, nil
+	if this.version >= WireVersion4 {
+		if err = this.unmarshalExtensions(body, pEnd); err != nil {
+			return err
+		}
+		if this.compression != CompressionNone {
+			if this.data, err = Decompress(this.data, this.compression); err != nil {
+				return invalid("data", pData)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateHeader checks that raw message bytes hold a complete routing header,
+// including the extended service name of WireVersion3 and above.
+// Call it before HeaderOf when the bytes come from an untrusted source.
+func ValidateHeader(data []byte) error {
+	if len(data) < PVersion {
+		return truncated("header", len(data))
+	}
+	_, err := headerSizeOf(data, VersionOf(data))
+	return err
+}
+
+// headerSizeOf returns the size of the unencrypted header, which is where the body starts.
+func headerSizeOf(data []byte, version byte) (int, error) {
+	if version == WireVersionLegacy {
+		return PVersion, nil
+	}
+	if version < WireVersion3 {
+		return pExtServiceName, nil
+	}
+	size, n := binary.Uvarint(data[pExtServiceName:])
+	if n == 0 {
+		return 0, truncated("serviceName", pExtServiceName)
+	}
+	if n < 0 {
+		return 0, invalid("serviceName", pExtServiceName)
+	}
+	end := pExtServiceName + n
+	if size > uint64(len(data)-end) {
+		return 0, truncated("serviceName", end)
+	}
+	return end + int(size), nil
+}
+
+// extServiceName returns the full service name of WireVersion3 and above frames.
+func extServiceName(data []byte) ([]byte, bool) {
+	if VersionOf(data) < WireVersion3 {
+		return nil, false
+	}
+	end, err := headerSizeOf(data, VersionOf(data))
+	if err != nil {
+		return nil, false
+	}
+	_, n := binary.Uvarint(data[pExtServiceName:])
+	return data[pExtServiceName+n : end], true
+}
+
+// textSize reads the size of a text field at the position, one byte before WireVersion3
+// and a uvarint from it. Returns the size and the number of bytes it occupies.
+func textSize(body []byte, pos int, version byte) (uint64, int, error) {
+	if pos >= len(body) {
+		return 0, 0, truncated("textSize", pos)
+	}
+	if version < WireVersion3 {
+		return uint64(body[pos]), sByte, nil
+	}
+	size, n := binary.Uvarint(body[pos:])
+	if n == 0 {
+		return 0, 0, truncated("textSize", pos)
+	}
+	if n < 0 {
+		return 0, 0, invalid("textSize", pos)
+	}
+	return size, n, nil
+}
+
+// decryptBody decrypts the message body according to the wire version.
+// WireVersion2 and above fail if either the header or the body were modified.
+func decryptBody(version byte, header, body []byte, security ISecurityProvider) ([]byte, error) {
+	if version < WireVersion2 {
+		return security.Decrypt(string(body))
+	}
+	aead, ok := security.(ISecurityProviderAEAD)
+	if !ok {
+		return nil, errors.New("security provider does not support authenticated encryption")
+	}
+	return aead.DecryptAEAD(string(body), header)
 }
 
 // HeaderOf extracts header fields from raw message bytes without full deserialization.
 // Returns: source, vnet, destination, serviceName, serviceArea, priority, multicastMode
+// Returns zero values if the bytes do not hold a complete header (see ValidateHeader).
 func HeaderOf(data []byte) (string, string, string, string, byte, Priority, MulticastMode) {
+	if len(data) < PVersion {
+		return "", "", "", "", 0, 0, 0
+	}
 	return unsafeString(data[pSource:pVnet]),
 		unsafeString(data[pVnet:pDestination]),
 		ToDestination(data),
@@ -99,9 +254,21 @@ func HeaderOf(data []byte) (string, string, string, string, byte, Priority, Mult
 		MulticastMode(data[PPriority] & 0x0F)
 }
 
+// VersionOf returns the wire version of raw message bytes.
+// Returns WireVersionLegacy for frames without a version marker.
+func VersionOf(data []byte) byte {
+	if len(data) > PVersion && data[PVersion]&wireVersionMark != 0 {
+		return data[PVersion] &^ wireVersionMark
+	}
+	return WireVersionLegacy
+}
+
 // ToDestination extracts the destination UUID from raw message bytes.
 // Returns empty string if destination is not set (multicast messages).
 func ToDestination(data []byte) string {
+	if len(data) < pServiceName {
+		return ""
+	}
 	if data[pDestination] != 0 && data[pDestination+1] != 0 {
 		return unsafeString(data[pDestination:pServiceName])
 	}
@@ -109,13 +276,20 @@ func ToDestination(data []byte) string {
 }
 
 // ToServiceName extracts the service name from raw message bytes.
-// Handles null-terminated strings within the fixed-size field.
+// Handles null-terminated strings within the fixed-size field,
+// and the full extended service name of WireVersion3 and above.
 func ToServiceName(data []byte) string {
+	if name, ok := extServiceName(data); ok {
+		return unsafeString(name)
+	}
 	start := pServiceName
 	end := start + sServiceName
 	if end > len(data) {
 		end = len(data)
 	}
+	if start >= end {
+		return ""
+	}
 
 	for i := start; i < end; i++ {
 		if data[i] == 0 {
@@ -135,6 +309,9 @@ func toDestinationSafe(data []byte) string {
 
 // toServiceNameSafe extracts service name with a copy (for Unmarshal where data is retained).
 func toServiceNameSafe(data []byte) string {
+	if name, ok := extServiceName(data); ok {
+		return string(name)
+	}
 	start := pServiceName
 	end := start + sServiceName
 	if end > len(data) {
@@ -45,6 +45,8 @@ type ISecurityProvider interface {
 	ValidateConnection(net.Conn, *l8sysconfig.L8SysConfig) error
 
 	// Encrypt encrypts data bytes to a string (typically base64).
+	// The string must be ASCII, e.g. base64: the body of a legacy message frame starts
+	// at the version marker position, and a byte with the high bit set is taken for the marker.
 	Encrypt([]byte) (string, error)
 	// Decrypt decrypts a string back to data bytes.
 	Decrypt(string) ([]byte, error)
@@ -86,6 +88,40 @@ type ISecurityProvider interface {
 	NewSystemConfig() *l8sysconfig.L8SysConfig
 }
 
+// ISecurityProviderAEAD is implemented by security providers that support authenticated
+// encryption with associated data. Message bodies of WireVersion2 and above are encrypted
+// through it, with the unencrypted message header bound as associated data so that any
+// change to the header or the body is detected. Encrypt/Decrypt of ISecurityProvider
+// remain in use for the handshake and for peers that negotiated an older wire version.
+type ISecurityProviderAEAD interface {
+	// EncryptAEAD encrypts the data and authenticates it with the associated data.
+	EncryptAEAD([]byte, []byte) (string, error)
+	// DecryptAEAD decrypts the data, failing if it or the associated data were modified.
+	DecryptAEAD(string, []byte) ([]byte, error)
+}
+
+// ISecurityProviderHandshake is implemented by security providers that validate connections
+// with the single round trip handshake of nets.Handshake, which needs to know which side
+// dialed the connection. Callers use it instead of ValidateConnection when the provider
+// implements it. When the dialer side fails with nets.ErrLegacyPeer, the remote node only
+// supports the legacy handshake, redial and validate the connection again, the provider
+// then uses the legacy handshake with that node.
+type ISecurityProviderHandshake interface {
+	// ValidateConnectionRole performs full connection validation with config,
+	// as the dialer of the connection when the bool is true, otherwise as the acceptor.
+	ValidateConnectionRole(net.Conn, *l8sysconfig.L8SysConfig, bool) error
+}
+
+// ISecurityProviderProof is implemented by security providers that prove in the handshake
+// that a node may connect, e.g. with a shared secret. nets.Handshake carries the proof in
+// the encrypted L8Hello, the legacy handshake exchanges it before the uuids.
+type ISecurityProviderProof interface {
+	// HandshakeProof returns the proof of this node.
+	HandshakeProof() []byte
+	// VerifyHandshakeProof returns an error if the proof of the remote node is not valid.
+	VerifyHandshakeProof([]byte) error
+}
+
 // ISecurityProviderLoader loads security provider plugins.
 type ISecurityProviderLoader interface {
 	// LoadSecurityProvider loads and initializes a security provider.
@@ -32,6 +32,7 @@ type IServices interface {
 	// DeActivate stops a service and removes it from the registry.
 	DeActivate(string, byte, IResources, IServiceCacheListener) error
 	// Handle routes a message to the appropriate service handler.
+	// Handlers get the message deadline as a context deadline with Message.Context.
 	Handle(IElements, Action, *Message, IVNic) IElements
 	// TransactionHandle routes a transactional message to the handler.
 	TransactionHandle(IElements, Action, *Message, IVNic) IElements
@@ -0,0 +1,184 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Stream.go defines streaming request/response with credit-based flow control.
+// A stream is opened with a StreamOpen message carrying the request and the
+// initial credit window. The service sends results as StreamData messages,
+// each consuming one credit, and the requester grants more credit with
+// StreamAck messages as it consumes them. Either side ends the stream with
+// StreamClose. All messages of a stream carry the stream id, which is the
+// sequence of the StreamOpen message.
+
+package ifs
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrStreamClosed is returned when sending on a closed stream.
+	ErrStreamClosed = errors.New("stream is closed")
+	// ErrStreamTimeout is returned when the peer did not grant credit in time.
+	ErrStreamTimeout = errors.New("timed out waiting for stream credit")
+)
+
+// IStream is one side of an open stream.
+type IStream interface {
+	// Id returns the stream id.
+	Id() uint32
+	// Send sends a batch of results, blocking until the peer granted credit.
+	Send(IElements) error
+	// Recv returns the next batch of results, blocking until one arrives.
+	// Returns io.EOF once the peer closed the stream.
+	Recv() (IElements, error)
+	// Close ends the stream, the peer receives io.EOF after the pending results.
+	Close() error
+}
+
+// IStreamingVNic is implemented by VNics that support streams. Check for it with a
+// type assertion on the IVNic, VNics without it only support request/response.
+type IStreamingVNic interface {
+	// Stream opens a stream to a service provider, whose results are delivered incrementally
+	// with credit-based flow control.
+	// Parameters: destination UUID, service name, service area, action, payload, credit window, optional AAA ID
+	Stream(string, string, byte, Action, interface{}, int, ...string) (IStream, error)
+}
+
+// IStreamService is implemented by service handlers that stream their results.
+type IStreamService interface {
+	// Stream handles a StreamOpen request, sending the results on the stream.
+	// The stream is closed when Stream returns, with the error if there is one.
+	Stream(IElements, IStream, IVNic) error
+}
+
+// StreamWindow is the sender side of the flow control. It holds the credit
+// granted by the peer, each sent StreamData message takes one credit.
+// Grant and Close wake all the waiting Acquire calls. Safe for concurrent use.
+type StreamWindow struct {
+	credit uint32
+	err    error
+	signal chan struct{} // closed and replaced to wake the waiters
+	mtx    sync.Mutex
+}
+
+// NewStreamWindow creates a window with the initial credit of the StreamOpen message.
+func NewStreamWindow(credit uint32) *StreamWindow {
+	return &StreamWindow{credit: credit, signal: make(chan struct{})}
+}
+
+// Acquire takes one credit, waiting up to the timeout for the peer to grant more.
+func (this *StreamWindow) Acquire(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		this.mtx.Lock()
+		if this.err != nil {
+			this.mtx.Unlock()
+			return this.err
+		}
+		if this.credit > 0 {
+			this.credit--
+			this.mtx.Unlock()
+			return nil
+		}
+		signal := this.signal
+		this.mtx.Unlock()
+		select {
+		case <-signal:
+		case <-timer.C:
+			return ErrStreamTimeout
+		}
+	}
+}
+
+// Grant adds the credit of a StreamAck message.
+func (this *StreamWindow) Grant(credit uint32) {
+	this.mtx.Lock()
+	if this.credit+credit < this.credit {
+		this.credit = 1<<32 - 1
+	} else {
+		this.credit += credit
+	}
+	this.notify()
+	this.mtx.Unlock()
+}
+
+// Credit returns the credit left.
+func (this *StreamWindow) Credit() uint32 {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.credit
+}
+
+// Close fails pending and future Acquire calls with the error, ErrStreamClosed if nil.
+func (this *StreamWindow) Close(err error) {
+	if err == nil {
+		err = ErrStreamClosed
+	}
+	this.mtx.Lock()
+	if this.err == nil {
+		this.err = err
+	}
+	this.notify()
+	this.mtx.Unlock()
+}
+
+// notify wakes all the waiting Acquire calls. Must be called with the lock held.
+func (this *StreamWindow) notify() {
+	close(this.signal)
+	this.signal = make(chan struct{})
+}
+
+// StreamCredit is the receiver side of the flow control. It counts the consumed
+// StreamData messages and tells when to return credit, batching the grants so
+// a StreamAck is sent once half of the window was consumed. Safe for concurrent use.
+type StreamCredit struct {
+	window   uint32
+	consumed uint32
+	mtx      sync.Mutex
+}
+
+// NewStreamCredit creates the receiver side for the window sent with StreamOpen.
+func NewStreamCredit(window uint32) *StreamCredit {
+	if window == 0 {
+		window = 1
+	}
+	return &StreamCredit{window: window}
+}
+
+// Window returns the credit window.
+func (this *StreamCredit) Window() uint32 {
+	return this.window
+}
+
+// Consume records a consumed StreamData message.
+// Returns the credit to grant with a StreamAck, 0 when no StreamAck is due yet.
+func (this *StreamCredit) Consume() uint32 {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.consumed++
+	threshold := this.window / 2
+	if threshold == 0 {
+		threshold = 1
+	}
+	if this.consumed < threshold {
+		return 0
+	}
+	credit := this.consumed
+	this.consumed = 0
+	return credit
+}
@@ -0,0 +1,171 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// TraceContext.go provides W3C Trace Context (traceparent) helpers so requests
+// passing through Request/Forward can be stitched into a single trace.
+// See https://www.w3.org/TR/trace-context/
+
+package ifs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	// MetadataTraceParent is the metadata key of the W3C traceparent value.
+	MetadataTraceParent = "traceparent"
+	// MetadataTraceState is the metadata key of the W3C tracestate value.
+	MetadataTraceState = "tracestate"
+
+	traceParentSize = 55
+	traceFlagSample = 0x01
+)
+
+// TraceParent is a parsed W3C traceparent value.
+type TraceParent struct {
+	// TraceId identifies the whole trace, 16 bytes
+	TraceId [16]byte
+	// SpanId identifies the caller span, 8 bytes
+	SpanId [8]byte
+	// Flags are the trace flags, bit 0 is "sampled"
+	Flags byte
+}
+
+// NewTraceParent starts a new trace with random trace and span IDs.
+func NewTraceParent(sampled bool) *TraceParent {
+	tp := &TraceParent{}
+	randomNonZero(tp.TraceId[:])
+	randomNonZero(tp.SpanId[:])
+	if sampled {
+		tp.Flags = traceFlagSample
+	}
+	return tp
+}
+
+// ParseTraceParent parses a version 00 traceparent value.
+// Values of a higher version are accepted as long as the version 00 fields are valid.
+func ParseTraceParent(value string) (*TraceParent, error) {
+	if len(value) < traceParentSize || (len(value) > traceParentSize && value[traceParentSize] != '-') {
+		return nil, errors.New("invalid traceparent length")
+	}
+	parts := strings.Split(value[:traceParentSize], "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return nil, errors.New("invalid traceparent format")
+	}
+	for _, part := range parts {
+		if strings.ToLower(part) != part {
+			return nil, errors.New("traceparent must be lowercase hex")
+		}
+	}
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || version[0] == 0xff {
+		return nil, errors.New("invalid traceparent version")
+	}
+	if version[0] == 0 && len(value) != traceParentSize {
+		return nil, errors.New("invalid traceparent length")
+	}
+	tp := &TraceParent{}
+	if _, err = hex.Decode(tp.TraceId[:], []byte(parts[1])); err != nil || isZero(tp.TraceId[:]) {
+		return nil, errors.New("invalid traceparent trace id")
+	}
+	if _, err = hex.Decode(tp.SpanId[:], []byte(parts[2])); err != nil || isZero(tp.SpanId[:]) {
+		return nil, errors.New("invalid traceparent span id")
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return nil, errors.New("invalid traceparent flags")
+	}
+	tp.Flags = flags[0]
+	return tp, nil
+}
+
+// String formats the traceparent as a version 00 value.
+func (this *TraceParent) String() string {
+	buff := make([]byte, 0, traceParentSize)
+	buff = append(buff, "00-"...)
+	buff = hex.AppendEncode(buff, this.TraceId[:])
+	buff = append(buff, '-')
+	buff = hex.AppendEncode(buff, this.SpanId[:])
+	buff = append(buff, '-')
+	buff = hex.AppendEncode(buff, []byte{this.Flags})
+	return string(buff)
+}
+
+// Sampled returns true if the sampled flag is set.
+func (this *TraceParent) Sampled() bool {
+	return this.Flags&traceFlagSample != 0
+}
+
+// Child returns a traceparent for a new span in the same trace.
+func (this *TraceParent) Child() *TraceParent {
+	child := &TraceParent{TraceId: this.TraceId, Flags: this.Flags}
+	randomNonZero(child.SpanId[:])
+	return child
+}
+
+// TraceParent returns the parsed traceparent metadata of the message, nil if there is none or it is invalid.
+func (this *Message) TraceParent() *TraceParent {
+	value, ok := this.metadata[MetadataTraceParent]
+	if !ok {
+		return nil
+	}
+	tp, err := ParseTraceParent(value)
+	if err != nil {
+		return nil
+	}
+	return tp
+}
+
+// SetTraceParent sets the traceparent metadata of the message.
+func (this *Message) SetTraceParent(tp *TraceParent) {
+	this.SetMetadata(MetadataTraceParent, tp.String())
+}
+
+// ContinueTrace moves the message to a new child span of its trace, or starts a new
+// sampled trace if it has none. Call it when a message is sent on, e.g. by Forward,
+// so every hop shows as its own span. Returns the new traceparent.
+func (this *Message) ContinueTrace() *TraceParent {
+	tp := this.TraceParent()
+	if tp == nil {
+		tp = NewTraceParent(true)
+	} else {
+		tp = tp.Child()
+	}
+	this.SetTraceParent(tp)
+	return tp
+}
+
+func randomNonZero(data []byte) {
+	for {
+		if _, err := rand.Read(data); err != nil {
+			panic("failed to generate trace id: " + err.Error())
+		}
+		if !isZero(data) {
+			return
+		}
+	}
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -80,6 +80,7 @@ type IVNic interface {
 	Unicast(string, string, byte, Action, interface{}) error
 	// Request sends a unicast message and waits for a response.
 	// Parameters: destination UUID, service name, service area, action, payload, timeout (ms), optional AAA ID
+	// The timeout is carried as an absolute deadline (Message.SetTimeoutDuration), so it is not capped at 65 seconds.
 	Request(string, string, byte, Action, interface{}, int, ...string) IElements
 	// Reply sends a response to a previous request.
 	Reply(*Message, IElements) error
@@ -110,6 +111,7 @@ type IVNic interface {
 	LocalRequest(string, byte, Action, interface{}, int, ...string) IElements
 
 	// Forward forwards a message to a specific destination.
+	// The message is dropped with a CloneFail reply when Message.Hop detects a loop or the hop limit is used up.
 	Forward(*Message, string) IElements
 	// ServiceAPI returns a simplified API for CRUD operations on a service.
 	ServiceAPI(string, byte) ServiceAPI
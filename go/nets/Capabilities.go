@@ -0,0 +1,96 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Capabilities.go provides wire capability negotiation for the Layer 8 protocol.
+// Capabilities ride along the services exchange of the handshake, so a peer
+// that does not know about them simply ignores the field and is treated as legacy.
+
+package nets
+
+import (
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8services"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// LocalCapabilities returns the wire capabilities supported by this node.
+// Wire versions that need authenticated encryption are only offered when
+// the security provider implements ifs.ISecurityProviderAEAD. Compression codecs
+// are offered along with WireVersion4, which carries the codec of the compressed data.
+// ifs.FeatureKeepalive is only offered by the nodes running Keepalive, see L8SysConfig.KeepAliveFrames.
+func LocalCapabilities(security ifs.ISecurityProvider) *l8services.L8WireCapabilities {
+	maxVersion := ifs.WireVersionMax
+	if _, ok := security.(ifs.ISecurityProviderAEAD); !ok {
+		maxVersion = ifs.WireVersion1
+	}
+	var features uint64
+	if maxVersion >= ifs.WireVersion4 {
+		features = ifs.FeatureCompressionFlate | ifs.FeatureCompressionGzip
+	}
+	return &l8services.L8WireCapabilities{
+		MinVersion: uint32(ifs.WireVersionMin),
+		MaxVersion: uint32(maxVersion),
+		Features:   features,
+	}
+}
+
+// NegotiateCapabilities picks the highest wire version and the common features
+// supported by both sides. A nil remote is a legacy peer.
+func NegotiateCapabilities(local, remote *l8services.L8WireCapabilities) (byte, uint64) {
+	if local == nil || remote == nil {
+		return ifs.WireVersionLegacy, 0
+	}
+	version := local.MaxVersion
+	if remote.MaxVersion < version {
+		version = remote.MaxVersion
+	}
+	if version < local.MinVersion || version < remote.MinVersion {
+		return ifs.WireVersionLegacy, 0
+	}
+	features := local.Features & remote.Features
+	if version < uint32(ifs.WireVersion4) {
+		// The codec of compressed data can only be carried from WireVersion4
+		features &^= ifs.FeatureCompressionFlate | ifs.FeatureCompressionGzip
+	}
+	return byte(version), features
+}
+
+// localCapabilities returns the LocalCapabilities of the node of the config, offering
+// ifs.FeatureKeepalive when the node runs Keepalive. Keepalive control frames are
+// handled at every wire version.
+func localCapabilities(config *l8sysconfig.L8SysConfig, security ifs.ISecurityProvider) *l8services.L8WireCapabilities {
+	capabilities := LocalCapabilities(security)
+	if config.GetKeepAliveFrames() {
+		capabilities.Features |= ifs.FeatureKeepalive
+	}
+	return capabilities
+}
+
+// servicesWithCapabilities returns a copy of the config services carrying the local capabilities.
+// The config services are not modified as they are shared with the rest of the node.
+func servicesWithCapabilities(config *l8sysconfig.L8SysConfig, security ifs.ISecurityProvider) *l8services.L8Services {
+	return &l8services.L8Services{
+		ServiceToAreas: config.Services.GetServiceToAreas(),
+		Capabilities:   localCapabilities(config, security),
+	}
+}
+
+// applyCapabilities negotiates with the remote capabilities and stores the result in the config.
+func applyCapabilities(config *l8sysconfig.L8SysConfig, remote *l8services.L8WireCapabilities,
+	security ifs.ISecurityProvider) {
+	version, features := NegotiateCapabilities(localCapabilities(config, security), remote)
+	config.WireVersion = uint32(version)
+	config.WireFeatures = features
+}
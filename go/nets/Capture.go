@@ -0,0 +1,219 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Capture.go provides capture of the frames read and written on a connection,
+// and their replay into a data listener to reproduce issues offline.
+// A capture file starts with a magic header followed by records of:
+// 8 bytes timestamp (Unix nanoseconds), 1 byte direction, 8 bytes size, data.
+
+package nets
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// CaptureDirection tells whether a captured frame was read or written.
+type CaptureDirection byte
+
+const (
+	CaptureIn  CaptureDirection = 1 // Frame read by Read
+	CaptureOut CaptureDirection = 2 // Frame written by Write
+)
+
+// captureMagic starts every capture file, the last byte is the format version.
+var captureMagic = []byte{'L', '8', 'C', 'A', 'P', 1}
+
+// maxCaptureRecord limits the size of a single record read from a capture file.
+const maxCaptureRecord = 1 << 32
+
+// CaptureRecord is a single captured frame.
+type CaptureRecord struct {
+	Time      time.Time
+	Direction CaptureDirection
+	Data      []byte
+}
+
+// Capture writes captured frames to a writer. Safe for concurrent use.
+type Capture struct {
+	writer io.Writer
+	err    error
+	mtx    sync.Mutex
+}
+
+// NewCapture creates a capture writing to the writer, starting with the capture file header.
+func NewCapture(writer io.Writer) (*Capture, error) {
+	if _, err := writer.Write(captureMagic); err != nil {
+		return nil, err
+	}
+	return &Capture{writer: writer}, nil
+}
+
+// Record writes a frame to the capture.
+func (this *Capture) Record(direction CaptureDirection, data []byte) error {
+	record := make([]byte, 0, 17+len(data))
+	record = append(record, ifs.Long2Bytes(time.Now().UnixNano())...)
+	record = append(record, byte(direction))
+	record = append(record, ifs.Long2Bytes(int64(len(data)))...)
+	record = append(record, data...)
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.err != nil {
+		return this.err
+	}
+	_, this.err = this.writer.Write(record)
+	return this.err
+}
+
+// Err returns the first error writing the capture, captured connections keep working when it fails.
+func (this *Capture) Err() error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.err
+}
+
+// capturedConn is a connection whose frames are recorded by Read and Write.
+type capturedConn struct {
+	net.Conn
+	capture *Capture
+}
+
+// CaptureConn returns the connection with the frames read by Read and written by Write recorded
+// to the capture. Wrap the connection after ValidateConnection to capture only message frames.
+// Other decorators, such as NewFaultConn or tls.Client, may wrap the captured connection,
+// the frames are recorded as long as every decorator above it has a NetConn method.
+func CaptureConn(conn net.Conn, capture *Capture) net.Conn {
+	return &capturedConn{Conn: conn, capture: capture}
+}
+
+// NetConn returns the captured connection.
+func (this *capturedConn) NetConn() net.Conn {
+	return this.Conn
+}
+
+// recordFrame records a frame if the connection is captured.
+func recordFrame(conn net.Conn, direction CaptureDirection, data []byte) {
+	if captured, ok := unwrapConn[*capturedConn](conn); ok {
+		captured.capture.Record(direction, data)
+	}
+}
+
+// unwrapConn returns the first connection of type T, following the NetConn method
+// of decorators from the outermost connection, as *tls.Conn and FaultConn have.
+func unwrapConn[T net.Conn](conn net.Conn) (T, bool) {
+	for conn != nil {
+		if found, ok := conn.(T); ok {
+			return found, true
+		}
+		wrapper, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		conn = wrapper.NetConn()
+	}
+	var none T
+	return none, false
+}
+
+// isMarkedFrame returns true for the frames starting with a marker, the hello, control,
+// mux and session ack frames, which are never messages.
+func isMarkedFrame(data []byte) bool {
+	for _, magic := range [][]byte{helloMagic, controlMagic, muxMagic, ackMagic} {
+		if bytes.HasPrefix(data, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// CaptureReader reads the records of a capture file.
+type CaptureReader struct {
+	reader io.Reader
+}
+
+// NewCaptureReader creates a reader of the capture, checking the capture file header.
+func NewCaptureReader(reader io.Reader) (*CaptureReader, error) {
+	magic := make([]byte, len(captureMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic, captureMagic) {
+		return nil, errors.New("not a capture file or unsupported capture version")
+	}
+	return &CaptureReader{reader: reader}, nil
+}
+
+// Next returns the next record, io.EOF at the end of the capture.
+func (this *CaptureReader) Next() (*CaptureRecord, error) {
+	header := make([]byte, 17)
+	if _, err := io.ReadFull(this.reader, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated capture record")
+		}
+		return nil, err
+	}
+	size := ifs.Bytes2Long(header[9:17])
+	if size < 0 || size > maxCaptureRecord {
+		return nil, errors.New("invalid capture record size")
+	}
+	record := &CaptureRecord{
+		Time:      time.Unix(0, ifs.Bytes2Long(header[0:8])),
+		Direction: CaptureDirection(header[8]),
+		Data:      make([]byte, size),
+	}
+	if _, err := io.ReadFull(this.reader, record.Data); err != nil {
+		return nil, errors.New("truncated capture record")
+	}
+	return record, nil
+}
+
+// Replay feeds the frames read in the capture, in order, to the listener as if they arrived
+// on the VNic. Frames with a marker, such as Handshake, keepalive and mux frames, and frames
+// without a valid message header are skipped. The frames of the legacy ExecuteProtocol
+// exchange have no marker and cannot always be told from messages, capture the connection
+// after ValidateConnection to keep them out of the capture.
+// With realtime, the original time between frames is kept. Returns the number of frames fed.
+func Replay(reader io.Reader, listener ifs.IDatatListener, vnic ifs.IVNic, realtime bool) (int, error) {
+	captureReader, err := NewCaptureReader(reader)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	var last time.Time
+	for {
+		record, err := captureReader.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		if record.Direction != CaptureIn || isMarkedFrame(record.Data) || ifs.ValidateHeader(record.Data) != nil {
+			continue
+		}
+		if realtime && !last.IsZero() {
+			time.Sleep(record.Time.Sub(last))
+		}
+		last = record.Time
+		listener.HandleData(record.Data, vnic)
+		count++
+	}
+}
@@ -0,0 +1,223 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// FaultConn.go provides a net.Conn decorator injecting network faults for chaos tests.
+// The faults are drawn from a generator seeded by the script, so a failing run
+// can be replayed with the same seed.
+
+package nets
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrFaultReset is returned by a FaultConn when the script resets the connection.
+var ErrFaultReset = errors.New("connection reset by fault injection")
+
+// FaultScript describes the faults injected by a FaultConn. Rates are the probability,
+// from 0 to 1, of the fault for each Write call.
+type FaultScript struct {
+	// Seed seeds the generators drawing the faults, one for each direction, so the
+	// faults of the writes do not depend on the reads.
+	Seed int64
+	// Latency delays every Read and Write.
+	Latency time.Duration
+	// Jitter adds a random delay of up to Jitter to every Read and Write.
+	Jitter time.Duration
+	// CorruptRate is the rate of writes with a random byte flipped.
+	CorruptRate float64
+	// PartialWriteRate is the rate of writes sending only part of the data, returning io.ErrShortWrite.
+	PartialWriteRate float64
+	// ResetRate is the rate of writes resetting the connection.
+	ResetRate float64
+	// ResetAfterBytes resets the connection once that many bytes were written, when not 0.
+	ResetAfterBytes int64
+}
+
+// FaultStats counts the faults injected by a FaultConn.
+type FaultStats struct {
+	Corrupted     int
+	PartialWrites int
+	Resets        int
+	// Dropped is the number of bytes written or received while partitioned.
+	Dropped int64
+}
+
+// FaultConn wraps a connection and injects the faults of its script. While partitioned,
+// the data written is silently dropped and the data received is discarded, as if the
+// network lost it. Safe for concurrent use.
+type FaultConn struct {
+	net.Conn
+	script      FaultScript
+	readRandom  *rand.Rand
+	writeRandom *rand.Rand
+	written     int64
+	partitioned bool
+	reset       bool
+	stats       FaultStats
+	mtx         sync.Mutex
+}
+
+// NewFaultConn wraps the connection with the fault script.
+func NewFaultConn(conn net.Conn, script FaultScript) *FaultConn {
+	return &FaultConn{Conn: conn, script: script,
+		readRandom:  rand.New(rand.NewSource(^script.Seed)),
+		writeRandom: rand.New(rand.NewSource(script.Seed))}
+}
+
+// NetConn returns the wrapped connection.
+func (this *FaultConn) NetConn() net.Conn {
+	return this.Conn
+}
+
+// Partition starts dropping the data in both directions until Heal is called.
+func (this *FaultConn) Partition() {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.partitioned = true
+}
+
+// Heal ends a partition.
+func (this *FaultConn) Heal() {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.partitioned = false
+}
+
+// Reset closes the connection, the following reads and writes return ErrFaultReset.
+func (this *FaultConn) Reset() {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.resetLocked()
+}
+
+// Stats returns the faults injected so far.
+func (this *FaultConn) Stats() FaultStats {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.stats
+}
+
+// Read reads from the connection after the latency. Data received while partitioned is discarded.
+func (this *FaultConn) Read(data []byte) (int, error) {
+	for {
+		this.delay(this.readRandom)
+		if err := this.checkReset(); err != nil {
+			return 0, err
+		}
+		n, err := this.Conn.Read(data)
+		this.mtx.Lock()
+		if this.reset {
+			this.mtx.Unlock()
+			return 0, ErrFaultReset
+		}
+		if !this.partitioned || n == 0 {
+			this.mtx.Unlock()
+			return n, err
+		}
+		this.stats.Dropped += int64(n)
+		this.mtx.Unlock()
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Write writes to the connection after the latency, injecting the faults of the script.
+func (this *FaultConn) Write(data []byte) (int, error) {
+	this.delay(this.writeRandom)
+	this.mtx.Lock()
+	if this.reset {
+		this.mtx.Unlock()
+		return 0, ErrFaultReset
+	}
+	if this.partitioned {
+		this.stats.Dropped += int64(len(data))
+		this.mtx.Unlock()
+		return len(data), nil
+	}
+	if this.chance(this.script.ResetRate) {
+		this.resetLocked()
+		this.mtx.Unlock()
+		return 0, ErrFaultReset
+	}
+	size := len(data)
+	var err error
+	if this.script.ResetAfterBytes > 0 && this.written+int64(size) >= this.script.ResetAfterBytes {
+		size = int(this.script.ResetAfterBytes - this.written)
+		err = ErrFaultReset
+	} else if size > 1 && this.chance(this.script.PartialWriteRate) {
+		size = 1 + this.writeRandom.Intn(size-1)
+		err = io.ErrShortWrite
+		this.stats.PartialWrites++
+	}
+	out := data[:size]
+	if size > 0 && this.chance(this.script.CorruptRate) {
+		out = append([]byte(nil), out...)
+		out[this.writeRandom.Intn(size)] ^= byte(1 + this.writeRandom.Intn(255))
+		this.stats.Corrupted++
+	}
+	this.written += int64(size)
+	this.mtx.Unlock()
+
+	n, werr := this.Conn.Write(out)
+	if werr != nil {
+		return n, werr
+	}
+	if err == ErrFaultReset {
+		this.Reset()
+	}
+	return n, err
+}
+
+// delay sleeps for the latency and a random jitter drawn from the random of the direction.
+func (this *FaultConn) delay(random *rand.Rand) {
+	this.mtx.Lock()
+	latency := this.script.Latency
+	if this.script.Jitter > 0 {
+		latency += time.Duration(random.Int63n(int64(this.script.Jitter)))
+	}
+	this.mtx.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+}
+
+func (this *FaultConn) checkReset() error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.reset {
+		return ErrFaultReset
+	}
+	return nil
+}
+
+// chance draws a write fault of the rate, faults that are not in the script draw nothing.
+func (this *FaultConn) chance(rate float64) bool {
+	return rate > 0 && this.writeRandom.Float64() < rate
+}
+
+func (this *FaultConn) resetLocked() {
+	if !this.reset {
+		this.reset = true
+		this.stats.Resets++
+		this.Conn.Close()
+	}
+}
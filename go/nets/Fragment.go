@@ -0,0 +1,39 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Fragment.go sizes message fragments so each marshaled fragment fits
+// within the MaxDataSize limit enforced by Write and Read.
+
+package nets
+
+import (
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// fragmentOverhead is the room kept in each frame for the message header and
+// the rest of the body, e.g. the fail message, transaction info and metadata.
+const fragmentOverhead = 64 * 1024
+
+// FragmentSize returns the data chunk size to pass to Message.Fragment for the config.
+// The encrypted body is base64 encoded, which grows it by a third, so the
+// chunk is sized to fit within MaxDataSize after the encoding.
+func FragmentSize(config *l8sysconfig.L8SysConfig) int {
+	size := int(config.MaxDataSize / 4 * 3)
+	overhead := fragmentOverhead
+	if overhead > size/2 {
+		overhead = size / 2
+	}
+	return size - overhead
+}
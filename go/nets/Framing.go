@@ -0,0 +1,229 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Framing.go provides context aware reading and writing of length-prefixed
+// frames. The context deadline is applied as the connection deadline, and
+// canceling the context interrupts a blocked read or write. Frames read by
+// ReadContext use pooled buffers, and frames are written with a single
+// vectored write of the size and the data.
+
+package nets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/bits"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+const (
+	// frameSizeLength is the length of the size prefix of a frame.
+	frameSizeLength = 8
+	// minPooledBits and maxPooledBits bound the size classes of pooled buffers, 512B to 16MB.
+	minPooledBits = 9
+	maxPooledBits = 24
+)
+
+var (
+	// ErrFrameTooLarge is returned when reading a frame whose size exceeds the config MaxDataSize.
+	ErrFrameTooLarge = errors.New("Max Size Exceeded!")
+	// ErrDataTooLarge is returned when writing data that exceeds the config MaxDataSize.
+	ErrDataTooLarge = errors.New("data is larger than MAX size allowed")
+)
+
+// bufferPools holds a pool per power of two size class.
+var bufferPools [maxPooledBits - minPooledBits + 1]sync.Pool
+
+// aLongTimeAgo is set as the connection deadline to interrupt a blocked read or write.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// GetBuffer returns a buffer of the size, taken from the buffer pool when the size is pooled.
+func GetBuffer(size int) []byte {
+	class := sizeClass(size)
+	if class < 0 {
+		return make([]byte, size)
+	}
+	if buffer, ok := bufferPools[class].Get().(*[]byte); ok {
+		return (*buffer)[:size]
+	}
+	return make([]byte, size, 1<<(class+minPooledBits))
+}
+
+// PutBuffer returns a buffer from GetBuffer or ReadContext to the pool. The buffer must not be used after.
+func PutBuffer(buffer []byte) {
+	class := sizeClass(cap(buffer))
+	if class < 0 || cap(buffer) != 1<<(class+minPooledBits) {
+		return
+	}
+	buffer = buffer[:0]
+	bufferPools[class].Put(&buffer)
+}
+
+// sizeClass returns the pool index of the size, -1 if buffers of the size are not pooled.
+func sizeClass(size int) int {
+	if size <= 0 || size > 1<<maxPooledBits {
+		return -1
+	}
+	class := bits.Len(uint(size-1)) - minPooledBits
+	if class < 0 {
+		class = 0
+	}
+	return class
+}
+
+// ReadContext reads a frame from the connection within the context. The returned data
+// is a pooled buffer, call PutBuffer with it once it is no longer used.
+// A deadline set on the connection for the context is cleared when ReadContext returns.
+func ReadContext(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig) ([]byte, error) {
+	return readFrame(ctx, conn, config, GetBuffer)
+}
+
+// WriteContext writes a frame to the connection within the context.
+// A deadline set on the connection for the context is cleared when WriteContext returns.
+func WriteContext(ctx context.Context, data []byte, conn net.Conn, config *l8sysconfig.L8SysConfig) error {
+	if err := validateWrite(data, conn, config); err != nil {
+		return err
+	}
+	stop := watchContext(ctx, conn.SetWriteDeadline)
+	buffers := net.Buffers{ifs.Long2Bytes(int64(len(data))), data}
+	_, err := buffers.WriteTo(conn)
+	stop()
+	if err != nil {
+		if ctxErr := contextError(ctx, err); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+	recordFrame(conn, CaptureOut, data)
+	return nil
+}
+
+// readFrame reads a frame into a buffer from alloc.
+func readFrame(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig, alloc func(int) []byte) ([]byte, error) {
+	// If the connection is nil, return an error
+	if conn == nil {
+		return nil, errors.New("no Connection Available")
+	}
+	// If the config is nil, error
+	if config == nil {
+		return nil, errors.New("no Config Available")
+	}
+	stop := watchContext(ctx, conn.SetReadDeadline)
+	defer stop()
+
+	// read 8 bytes, e.g. long, hinting of the size of the byte array
+	var sizeBytes [frameSizeLength]byte
+	if err := readFull(ctx, conn, sizeBytes[:]); err != nil {
+		return nil, err
+	}
+	size := ifs.Bytes2Long(sizeBytes[:])
+	// If the size is larger than the MAX Data Size, return an error
+	// this is to protect against overflowing the buffers
+	// When data to send is > the max data size, one needs to split the data into chunks at a higher level,
+	// see Message.Fragment and FragmentSize
+	if size < 0 || uint64(size) > config.MaxDataSize {
+		return nil, ErrFrameTooLarge
+	}
+	data := alloc(int(size))
+	if err := readFull(ctx, conn, data); err != nil {
+		return nil, err
+	}
+	recordFrame(conn, CaptureIn, data)
+	return data, nil
+}
+
+// readFull reads exactly len(data) bytes, returning the context error if the context ended the read.
+func readFull(ctx context.Context, conn net.Conn, data []byte) error {
+	n, err := io.ReadFull(conn, data)
+	if err == nil {
+		return nil
+	}
+	if ctxErr := contextError(ctx, err); ctxErr != nil {
+		return ctxErr
+	}
+	if n == 0 {
+		return errors.New("Failed to read data size:" + err.Error())
+	}
+	return errors.New("Failed to read packet size:" + err.Error())
+}
+
+// contextError returns the context error when the context ended the operation that failed with err.
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// The connection deadline may expire just before the context reports it
+	if _, ok := ctx.Deadline(); ok && errors.Is(err, os.ErrDeadlineExceeded) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// validateWrite checks the arguments of a frame write.
+func validateWrite(data []byte, conn net.Conn, config *l8sysconfig.L8SysConfig) error {
+	// If the connection is nil, return an error
+	if conn == nil {
+		return errors.New("no Connection Available")
+	}
+	// If the config is nil, error
+	if config == nil {
+		return errors.New("no Config Available")
+	}
+	if data == nil {
+		return errors.New("no Data Available")
+	}
+	// Error is the data is too big
+	if len(data) > int(config.MaxDataSize) {
+		return ErrDataTooLarge
+	}
+	return nil
+}
+
+// watchContext applies the context deadline to the connection with setDeadline, and
+// interrupts a blocked operation when the context is canceled. The returned stop
+// function must be called when the operation is done, it clears the deadline it set.
+func watchContext(ctx context.Context, setDeadline func(time.Time) error) func() {
+	deadline, hasDeadline := ctx.Deadline()
+	if hasDeadline {
+		setDeadline(deadline)
+	}
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-ctx.Done():
+			setDeadline(aLongTimeAgo)
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+		if hasDeadline || ctx.Err() != nil {
+			setDeadline(time.Time{})
+		}
+	}
+}
@@ -0,0 +1,284 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Handshake.go provides the single round trip connection handshake. The dialer
+// sends an L8Hello and the acceptor replies with an L8HelloAck, replacing the
+// secret exchange and the five exchanges of ExecuteProtocol. New fields can be
+// added to the messages without breaking peers, and the handshake protocol
+// version is negotiated.
+
+package nets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8services"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+	"google.golang.org/protobuf/proto"
+)
+
+// HandshakeVersion is the highest handshake protocol version supported by this node.
+const HandshakeVersion uint32 = 1
+
+// helloMagic starts the handshake frames. The legacy handshake starts with a uuid,
+// which never contains a NUL byte, so the two can be told apart.
+var helloMagic = []byte("\x00L8HELLO")
+
+// ErrLegacyPeer is returned by Handshake on the dialer side when the remote node only
+// supports the legacy handshake. The connection is closed, redial and use LegacyHandshake.
+var ErrLegacyPeer = errors.New("remote node only supports the legacy handshake")
+
+// Handshake performs the connection handshake, exchanging the same information as
+// ExecuteProtocol in a single round trip. The dialer sends an L8Hello and the
+// acceptor replies with an L8HelloAck, both carrying the proof of a provider
+// implementing ifs.ISecurityProviderProof. A dialer also completes the handshake
+// when the remote node sent its own L8Hello as a dialer. An acceptor falls back
+// to the legacy handshake when the dialer started it. The negotiated handshake version is
+// stored in config.HandshakeVersion, 0 after the legacy handshake.
+// The handshake must complete within config.HandshakeTimeoutSeconds, and
+// failures are returned as a *HandshakeError.
+func Handshake(conn net.Conn, config *l8sysconfig.L8SysConfig, security ifs.ISecurityProvider, dialer bool) error {
+	ctx, cancel := NewHandshakeContext(config)
+	defer cancel()
+	return HandshakeContext(ctx, conn, config, security, dialer)
+}
+
+// HandshakeContext performs Handshake within the context.
+func HandshakeContext(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider, dialer bool) error {
+	if dialer {
+		return dialHandshake(ctx, conn, config, security)
+	}
+	return acceptHandshake(ctx, conn, config, security)
+}
+
+// WriteHandshakeFrame encrypts and writes a frame of the handshake step within the context.
+// On failure the connection is closed and a *HandshakeError is returned.
+func WriteHandshakeFrame(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider, step string, data []byte) error {
+	encData, err := security.Encrypt(data)
+	if err != nil {
+		conn.Close()
+		return NewHandshakeError(step, ErrHandshakeEncrypt, err)
+	}
+	if err = WriteContext(ctx, []byte(encData), conn, config); err != nil {
+		conn.Close()
+		return ioHandshakeError(step, err)
+	}
+	return nil
+}
+
+// ReadHandshakeFrame reads and decrypts a frame of the handshake step within the context.
+// On failure the connection is closed and a *HandshakeError is returned.
+func ReadHandshakeFrame(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider, step string) ([]byte, error) {
+	inData, err := ReadContext(ctx, conn, config)
+	if err != nil {
+		conn.Close()
+		return nil, ioHandshakeError(step, err)
+	}
+	data, err := security.Decrypt(string(inData))
+	PutBuffer(inData)
+	if err != nil {
+		conn.Close()
+		return nil, NewHandshakeError(step, ErrHandshakeDecrypt, err)
+	}
+	return data, nil
+}
+
+// dialHandshake sends the hello and applies the ack of the acceptor.
+func dialHandshake(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider) error {
+	_, err := dialHello(ctx, conn, config, security, localHello(config, security, HandshakeVersion))
+	return err
+}
+
+// dialHello sends the hello, applies the ack of the acceptor and returns it.
+func dialHello(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider, hello *l8sysconfig.L8Hello) (*l8sysconfig.L8HelloAck, error) {
+	err := writeHandshake(ctx, conn, config, security, "hello", hello)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ReadHandshakeFrame(ctx, conn, config, security, "ack")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, helloMagic) {
+		// A legacy acceptor starts the legacy handshake by sending its proof,
+		// or its uuid when the provider has none
+		if err = verifyProof(conn, security, data); err != nil {
+			return nil, err
+		}
+		conn.Close()
+		return nil, NewHandshakeError("ack", ErrLegacyPeer, nil)
+	}
+	ack := &l8sysconfig.L8HelloAck{}
+	if err = proto.Unmarshal(data[len(helloMagic):], ack); err != nil {
+		conn.Close()
+		return nil, NewHandshakeError("ack", ErrHandshakeInvalid, err)
+	}
+	if ack.Hello == nil {
+		// The remote node validates the connection as a dialer too and sent its hello
+		ack.Hello = &l8sysconfig.L8Hello{}
+		if err = proto.Unmarshal(data[len(helloMagic):], ack.Hello); err != nil {
+			conn.Close()
+			return nil, NewHandshakeError("ack", ErrHandshakeInvalid, err)
+		}
+		if ack.Hello.ProtocolVersion > hello.ProtocolVersion {
+			ack.Hello.ProtocolVersion = hello.ProtocolVersion
+		}
+	}
+	if err = verifyProof(conn, security, ack.Hello.Proof); err != nil {
+		return nil, err
+	}
+	if ack.Hello.ProtocolVersion == 0 || ack.Hello.ProtocolVersion > HandshakeVersion {
+		conn.Close()
+		return nil, NewHandshakeError("ack", ErrHandshakeVersion, nil)
+	}
+	applyHello(config, ack.Hello, security)
+	return ack, nil
+}
+
+// acceptHandshake reads the hello of the dialer and replies with the ack,
+// or continues the legacy handshake when the dialer started it.
+func acceptHandshake(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider) error {
+	_, err := acceptHello(ctx, conn, config, security, nil)
+	return err
+}
+
+// acceptHello reads the hello of the dialer, replies with the ack and returns the hello.
+// The reply function, if not nil, can add to the ack before it is sent. Returns a nil
+// hello after continuing the legacy handshake when the dialer started it.
+func acceptHello(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider, reply func(*l8sysconfig.L8Hello, *l8sysconfig.L8HelloAck)) (*l8sysconfig.L8Hello, error) {
+	data, err := ReadHandshakeFrame(ctx, conn, config, security, "hello")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, helloMagic) {
+		return nil, acceptLegacy(ctx, conn, config, security, data)
+	}
+	hello := &l8sysconfig.L8Hello{}
+	if err = proto.Unmarshal(data[len(helloMagic):], hello); err != nil {
+		conn.Close()
+		return nil, NewHandshakeError("hello", ErrHandshakeInvalid, err)
+	}
+	if err = verifyProof(conn, security, hello.Proof); err != nil {
+		return nil, err
+	}
+	if hello.ProtocolVersion == 0 {
+		conn.Close()
+		return nil, NewHandshakeError("hello", ErrHandshakeVersion, nil)
+	}
+	version := HandshakeVersion
+	if hello.ProtocolVersion < version {
+		version = hello.ProtocolVersion
+	}
+	ack := &l8sysconfig.L8HelloAck{Hello: localHello(config, security, version)}
+	if reply != nil {
+		reply(hello, ack)
+	}
+	if err = writeHandshake(ctx, conn, config, security, "ack", ack); err != nil {
+		return nil, err
+	}
+	hello.ProtocolVersion = version
+	applyHello(config, hello, security)
+	return hello, nil
+}
+
+// acceptLegacy continues the legacy handshake the dialer started with the first frame,
+// its proof when the provider requires one, otherwise its uuid.
+func acceptLegacy(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider, data []byte) error {
+	if prover, ok := security.(ifs.ISecurityProviderProof); ok {
+		if err := verifyProof(conn, security, data); err != nil {
+			return err
+		}
+		err := WriteHandshakeFrame(ctx, conn, config, security, "secret", prover.HandshakeProof())
+		if err != nil {
+			return err
+		}
+		return ExecuteProtocolContext(ctx, conn, config, security)
+	}
+	config.RemoteUuid = string(data)
+	err := WriteHandshakeFrame(ctx, conn, config, security, "uuid", []byte(config.LocalUuid))
+	if err != nil {
+		return err
+	}
+	return executeLegacyProtocol(ctx, conn, config, security)
+}
+
+// verifyProof verifies the proof of the remote node when the provider requires one.
+// On failure the connection is closed and a *HandshakeError is returned.
+func verifyProof(conn net.Conn, security ifs.ISecurityProvider, proof []byte) error {
+	prover, ok := security.(ifs.ISecurityProviderProof)
+	if !ok {
+		return nil
+	}
+	if err := prover.VerifyHandshakeProof(proof); err != nil {
+		conn.Close()
+		return NewHandshakeError("secret", ErrHandshakeBadSecret, err)
+	}
+	return nil
+}
+
+// localHello returns the hello of this node for the config.
+func localHello(config *l8sysconfig.L8SysConfig, security ifs.ISecurityProvider, version uint32) *l8sysconfig.L8Hello {
+	hello := &l8sysconfig.L8Hello{
+		ProtocolVersion: version,
+		Uuid:            config.LocalUuid,
+		Alias:           config.LocalAlias,
+		ForceExternal:   config.ForceExternal,
+		Services:        &l8services.L8Services{ServiceToAreas: config.Services.GetServiceToAreas()},
+		Vnet:            config.RemoteVnet,
+		Capabilities:    localCapabilities(config, security),
+	}
+	if prover, ok := security.(ifs.ISecurityProviderProof); ok {
+		hello.Proof = prover.HandshakeProof()
+	}
+	return hello
+}
+
+// applyHello stores the remote node information in the config, the same way ExecuteProtocol does.
+func applyHello(config *l8sysconfig.L8SysConfig, hello *l8sysconfig.L8Hello, security ifs.ISecurityProvider) {
+	config.HandshakeVersion = hello.ProtocolVersion
+	config.RemoteUuid = hello.Uuid
+	if hello.ForceExternal {
+		config.ForceExternal = true
+	}
+	config.RemoteAlias = hello.Alias
+	applyCapabilities(config, hello.Capabilities, security)
+	config.Services = hello.Services
+	if config.RemoteVnet == "" {
+		config.RemoteVnet = hello.Vnet
+	}
+}
+
+// writeHandshake writes a handshake message prefixed with helloMagic.
+func writeHandshake(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider, step string, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		conn.Close()
+		return NewHandshakeError(step, ErrHandshakeInvalid, err)
+	}
+	return WriteHandshakeFrame(ctx, conn, config, security, step, append(append([]byte{}, helloMagic...), data...))
+}
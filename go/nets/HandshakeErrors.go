@@ -0,0 +1,107 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// HandshakeErrors.go defines the errors returned when a connection handshake fails.
+// Callers can check the cause with errors.Is and the details with errors.As, and
+// use Retryable to tell a transient failure from one that needs attention.
+
+package nets
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// DefaultHandshakeTimeout is the time allowed for a handshake when the config does not set one.
+const DefaultHandshakeTimeout = 30 * time.Second
+
+var (
+	// ErrHandshakeBadSecret is returned when the remote node uses a different secret.
+	ErrHandshakeBadSecret = errors.New("incorrect Secret/Key, aborting connection")
+	// ErrHandshakeVersion is returned when the nodes have no handshake version in common.
+	ErrHandshakeVersion = errors.New("unsupported handshake version")
+	// ErrHandshakeFrameSize is returned when a handshake frame exceeds the config MaxDataSize.
+	ErrHandshakeFrameSize = errors.New("handshake frame is larger than MAX size allowed")
+	// ErrHandshakeTimeout is returned when the handshake did not complete within its deadline.
+	ErrHandshakeTimeout = errors.New("handshake timed out")
+	// ErrHandshakeDecrypt is returned when a handshake frame cannot be decrypted.
+	ErrHandshakeDecrypt = errors.New("failed to decrypt handshake frame")
+	// ErrHandshakeEncrypt is returned when a handshake frame cannot be encrypted.
+	ErrHandshakeEncrypt = errors.New("failed to encrypt handshake frame")
+	// ErrHandshakeInvalid is returned when a decrypted handshake frame cannot be decoded.
+	ErrHandshakeInvalid = errors.New("invalid handshake frame")
+	// ErrHandshakeConnection is returned when the connection failed during the handshake.
+	ErrHandshakeConnection = errors.New("handshake connection failed")
+)
+
+// HandshakeError describes which step of the handshake failed.
+type HandshakeError struct {
+	// Step is the name of the handshake step that failed, e.g. "secret" or "hello"
+	Step string
+	// Err is the cause, one of the ErrHandshake errors or ErrLegacyPeer
+	Err error
+	// Cause is the underlying error, if any
+	Cause error
+}
+
+func (this *HandshakeError) Error() string {
+	msg := "handshake " + this.Step + ": " + this.Err.Error()
+	if this.Cause != nil {
+		msg += ": " + this.Cause.Error()
+	}
+	return msg
+}
+
+func (this *HandshakeError) Unwrap() []error {
+	if this.Cause == nil {
+		return []error{this.Err}
+	}
+	return []error{this.Err, this.Cause}
+}
+
+// Retryable returns true if the handshake may succeed when retried, e.g. after a
+// timeout or a dropped connection. Other failures, such as a bad secret or a
+// version mismatch, will fail again until the configuration is fixed.
+func (this *HandshakeError) Retryable() bool {
+	return this.Err == ErrHandshakeTimeout || this.Err == ErrHandshakeConnection || this.Err == ErrLegacyPeer
+}
+
+// NewHandshakeError creates the error of a failed handshake step.
+func NewHandshakeError(step string, err, cause error) *HandshakeError {
+	return &HandshakeError{Step: step, Err: err, Cause: cause}
+}
+
+// NewHandshakeContext returns a context with the handshake deadline of the config.
+func NewHandshakeContext(config *l8sysconfig.L8SysConfig) (context.Context, context.CancelFunc) {
+	timeout := DefaultHandshakeTimeout
+	if config.GetHandshakeTimeoutSeconds() > 0 {
+		timeout = time.Duration(config.GetHandshakeTimeoutSeconds()) * time.Second
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// ioHandshakeError classifies an error reading or writing a handshake frame.
+func ioHandshakeError(step string, err error) *HandshakeError {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewHandshakeError(step, ErrHandshakeTimeout, err)
+	case errors.Is(err, ErrFrameTooLarge) || errors.Is(err, ErrDataTooLarge):
+		return NewHandshakeError(step, ErrHandshakeFrameSize, err)
+	}
+	return NewHandshakeError(step, ErrHandshakeConnection, err)
+}
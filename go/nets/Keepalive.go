@@ -0,0 +1,269 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Keepalive.go provides ping/pong control frames and dead-peer detection.
+// Control frames are written with Write like messages, but start with a marker
+// that a message header never starts with, so the reader can tell them apart:
+// marker, 1 byte type, 8 bytes sequence, 8 bytes send time (Unix nanoseconds).
+// A peer that does not know control frames would take them for messages, so pings
+// are only sent when both sides negotiated ifs.FeatureKeepalive in the handshake,
+// which a node offers when its config KeepAliveFrames is set.
+
+package nets
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8health"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+const (
+	// ControlPing asks the remote side to answer with a ControlPong.
+	ControlPing byte = 1
+	// ControlPong answers a ControlPing, echoing its sequence and send time.
+	ControlPong byte = 2
+)
+
+const (
+	// DefaultKeepAliveInterval is the time between pings when the config does not set one.
+	DefaultKeepAliveInterval = 30 * time.Second
+	// DefaultKeepAliveMissedBeats is the number of intervals without any frame from
+	// the remote side before it is unreachable, when the config does not set one.
+	DefaultKeepAliveMissedBeats = 3
+)
+
+// controlMagic starts every control frame. Message frames start with the source
+// uuid, which never contains a NUL byte.
+var controlMagic = []byte("\x00L8CTL")
+
+// controlFrameSize is the size of a control frame.
+var controlFrameSize = len(controlMagic) + 17
+
+// IsControlFrame returns true if the frame is a keepalive control frame rather than a message.
+func IsControlFrame(data []byte) bool {
+	return len(data) == controlFrameSize && bytes.HasPrefix(data, controlMagic)
+}
+
+// ControlFrame creates a control frame of the type.
+func ControlFrame(controlType byte, sequence uint64, sent time.Time) []byte {
+	data := make([]byte, 0, controlFrameSize)
+	data = append(data, controlMagic...)
+	data = append(data, controlType)
+	data = append(data, ifs.Long2Bytes(int64(sequence))...)
+	return append(data, ifs.Long2Bytes(sent.UnixNano())...)
+}
+
+// parseControlFrame returns the type, sequence and send time of a control frame.
+func parseControlFrame(data []byte) (byte, uint64, time.Time) {
+	pos := len(controlMagic)
+	return data[pos], uint64(ifs.Bytes2Long(data[pos+1 : pos+9])), time.Unix(0, ifs.Bytes2Long(data[pos+9:pos+17]))
+}
+
+// Keepalive sends pings to the remote side every interval, answers its pings and tracks
+// its health. Any frame received from the remote side counts as a beat. After the
+// missed beats threshold the remote side is Unreachable, and it is Up again when a
+// frame arrives. A failure to send marks it Down. Safe for concurrent use.
+type Keepalive struct {
+	enabled   bool
+	interval  time.Duration
+	maxMissed int
+	send      func([]byte) error
+	onState   func(l8health.L8HealthState)
+	sequence  uint64
+	received  bool
+	missed    int
+	rtt       time.Duration
+	state     l8health.L8HealthState
+	stop      chan struct{}
+	mtx       sync.Mutex
+}
+
+// NewKeepalive creates a keepalive using the config KeepAliveIntervalSeconds and KeepAliveMissedBeats.
+// The config is the one of the connection handshake, the keepalive is disabled unless its
+// WireFeatures has ifs.FeatureKeepalive, see Enabled. Set the config KeepAliveFrames before the
+// handshake so it offers the feature.
+// The send function writes a control frame to the remote side, e.g. with Write or through a
+// priority queue at P1 so it is not stuck behind bulk data. The onState function, if not nil,
+// is called when the health state of the remote side changes.
+func NewKeepalive(config *l8sysconfig.L8SysConfig, send func([]byte) error,
+	onState func(l8health.L8HealthState)) *Keepalive {
+	interval := DefaultKeepAliveInterval
+	if config.KeepAliveIntervalSeconds > 0 {
+		interval = time.Duration(config.KeepAliveIntervalSeconds) * time.Second
+	}
+	maxMissed := DefaultKeepAliveMissedBeats
+	if config.KeepAliveMissedBeats > 0 {
+		maxMissed = int(config.KeepAliveMissedBeats)
+	}
+	return &Keepalive{enabled: config.WireFeatures&ifs.FeatureKeepalive != 0, interval: interval,
+		maxMissed: maxMissed, send: send, onState: onState, state: l8health.L8HealthState_Up}
+}
+
+// Enabled returns true if the remote side handles control frames. A disabled keepalive
+// sends no pings and does not track missed beats, the remote side stays Up.
+func (this *Keepalive) Enabled() bool {
+	return this.enabled
+}
+
+// Start sends a ping every interval until Stop is called. Does nothing if not Enabled.
+func (this *Keepalive) Start() {
+	this.mtx.Lock()
+	if this.stop != nil || !this.enabled {
+		this.mtx.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	this.stop = stop
+	this.mtx.Unlock()
+	go func() {
+		ticker := time.NewTicker(this.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				this.Tick()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops sending pings.
+func (this *Keepalive) Stop() {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.stop != nil {
+		close(this.stop)
+		this.stop = nil
+	}
+}
+
+// Tick counts a missed beat if nothing was received since the last tick, and sends a ping.
+// Start calls it every interval. Does nothing if not Enabled.
+func (this *Keepalive) Tick() {
+	this.mtx.Lock()
+	if this.state == l8health.L8HealthState_Down || !this.enabled {
+		this.mtx.Unlock()
+		return
+	}
+	if this.received {
+		this.missed = 0
+	} else {
+		this.missed++
+	}
+	this.received = false
+	changed := false
+	if this.missed >= this.maxMissed && this.state != l8health.L8HealthState_Unreachable {
+		this.state = l8health.L8HealthState_Unreachable
+		changed = true
+	}
+	this.sequence++
+	frame := ControlFrame(ControlPing, this.sequence, time.Now())
+	this.mtx.Unlock()
+
+	if changed {
+		this.notify(l8health.L8HealthState_Unreachable)
+	}
+	if err := this.send(frame); err != nil {
+		this.setState(l8health.L8HealthState_Down)
+	}
+}
+
+// HandleFrame records a frame received from the remote side. Pings are answered with a
+// pong and pongs update the round trip time. Returns true if the frame was a control
+// frame, which should not be handled as a message.
+func (this *Keepalive) HandleFrame(data []byte) bool {
+	this.mtx.Lock()
+	this.received = true
+	this.missed = 0
+	recovered := this.state == l8health.L8HealthState_Unreachable
+	if recovered {
+		this.state = l8health.L8HealthState_Up
+	}
+	this.mtx.Unlock()
+	if recovered {
+		this.notify(l8health.L8HealthState_Up)
+	}
+
+	if !IsControlFrame(data) {
+		return false
+	}
+	controlType, sequence, sent := parseControlFrame(data)
+	switch controlType {
+	case ControlPing:
+		if err := this.send(ControlFrame(ControlPong, sequence, sent)); err != nil {
+			this.setState(l8health.L8HealthState_Down)
+		}
+	case ControlPong:
+		this.updateRTT(time.Since(sent))
+	}
+	return true
+}
+
+// RTT returns the smoothed round trip time to the remote side, 0 before the first pong.
+func (this *Keepalive) RTT() time.Duration {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.rtt
+}
+
+// State returns the health state of the remote side.
+func (this *Keepalive) State() l8health.L8HealthState {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.state
+}
+
+// Missed returns the number of beats missed in a row.
+func (this *Keepalive) Missed() int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.missed
+}
+
+// updateRTT smooths the round trip time samples the same way TCP does, 1/8 of each new sample.
+func (this *Keepalive) updateRTT(sample time.Duration) {
+	if sample < 0 {
+		return
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.rtt == 0 {
+		this.rtt = sample
+		return
+	}
+	this.rtt += (sample - this.rtt) / 8
+}
+
+func (this *Keepalive) setState(state l8health.L8HealthState) {
+	this.mtx.Lock()
+	changed := this.state != state
+	this.state = state
+	this.mtx.Unlock()
+	if changed {
+		this.notify(state)
+	}
+}
+
+func (this *Keepalive) notify(state l8health.L8HealthState) {
+	if this.onState != nil {
+		this.onState(state)
+	}
+}
@@ -0,0 +1,537 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Mux.go multiplexes logical streams over a single connection, so a large transfer
+// does not block small high-priority replies behind it. Stream writes are split in
+// chunks sent as mux frames with Write, interleaved by the weighted round robin of a
+// PriorityQueue at the stream priority. A stream sends at most the window granted by
+// the remote side, which grants more as the data is read, so a stalled stream does not
+// stall the others. Mux frames start with a marker that a message header never starts
+// with: marker, 1 byte type, 4 bytes stream id, payload.
+
+package nets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+const (
+	// MuxOpen opens a stream, the payload is the stream priority.
+	MuxOpen byte = 1
+	// MuxData carries a chunk of stream data.
+	MuxData byte = 2
+	// MuxWindow grants the remote side more window, the payload is the 4 bytes increment.
+	MuxWindow byte = 3
+	// MuxClose closes a stream, the remote side reads io.EOF after the data it received.
+	MuxClose byte = 4
+)
+
+const (
+	// MuxChunkSize is the largest data chunk of a mux frame.
+	MuxChunkSize = 16 * 1024
+	// MuxWindowSize is the data a stream may send before the remote side reads it.
+	MuxWindowSize = 256 * 1024
+	// MuxMaxStreams is the most streams each side may have open at once, including
+	// the streams opened by the remote side and not accepted yet.
+	MuxMaxStreams = 256
+)
+
+var (
+	// ErrMuxClosed is returned by the mux and its streams after Close.
+	ErrMuxClosed = errors.New("mux is closed")
+	// ErrMuxProtocol is returned when the remote side sent an invalid mux frame, the mux is then closed.
+	ErrMuxProtocol = errors.New("invalid mux frame")
+	// ErrMuxStreams is returned by Open when MuxMaxStreams streams are open.
+	ErrMuxStreams = errors.New("too many open mux streams")
+)
+
+// muxMagic starts every mux frame. Message frames start with the source
+// uuid, which never contains a NUL byte.
+var muxMagic = []byte("\x00L8MUX")
+
+// muxHeaderSize is the size of a mux frame before the payload.
+var muxHeaderSize = len(muxMagic) + 5
+
+// IsMuxFrame returns true if the frame is a mux frame rather than a message.
+func IsMuxFrame(data []byte) bool {
+	return len(data) >= muxHeaderSize && bytes.HasPrefix(data, muxMagic)
+}
+
+func muxFrame(frameType byte, id uint32, payload []byte) []byte {
+	data := make([]byte, 0, muxHeaderSize+len(payload))
+	data = append(data, muxMagic...)
+	data = append(data, frameType)
+	data = binary.BigEndian.AppendUint32(data, id)
+	return append(data, payload...)
+}
+
+// Mux multiplexes streams over a connection. Both sides of the connection must use a mux.
+// Safe for concurrent use.
+type Mux struct {
+	conn    net.Conn
+	config  *l8sysconfig.L8SysConfig
+	queue   *PriorityQueue
+	streams map[uint32]*MuxStream
+	pending []*MuxStream
+	nextId  uint32
+	// opened is the number of open streams opened by this side and by the remote side
+	opened     [2]int
+	err        error
+	acceptable *sync.Cond
+	mtx        sync.Mutex
+}
+
+// NewMux starts multiplexing streams over the connection, once the handshake completed.
+// The dialer side opens odd stream ids and the acceptor side even ones, so both sides
+// can open streams.
+func NewMux(conn net.Conn, config *l8sysconfig.L8SysConfig, dialer bool) *Mux {
+	mux := &Mux{
+		conn:    conn,
+		config:  config,
+		queue:   NewPriorityQueue(0, OverflowBlock),
+		streams: make(map[uint32]*MuxStream),
+		nextId:  2,
+	}
+	if dialer {
+		mux.nextId = 1
+	}
+	mux.acceptable = sync.NewCond(&mux.mtx)
+	go mux.read()
+	go mux.write()
+	return mux
+}
+
+// Open opens a stream whose data is sent at the priority. Fails with ErrMuxStreams
+// when MuxMaxStreams streams opened by this side are open.
+func (this *Mux) Open(priority ifs.Priority) (*MuxStream, error) {
+	if priority > ifs.P1 {
+		return nil, fmt.Errorf("invalid stream priority %d", priority)
+	}
+	this.mtx.Lock()
+	if this.err != nil {
+		this.mtx.Unlock()
+		return nil, this.err
+	}
+	if this.opened[0] >= MuxMaxStreams {
+		this.mtx.Unlock()
+		return nil, ErrMuxStreams
+	}
+	stream := newMuxStream(this, this.nextId, priority)
+	this.nextId += 2
+	this.streams[stream.id] = stream
+	this.opened[0]++
+	this.mtx.Unlock()
+	if err := this.send(MuxOpen, stream.id, []byte{byte(priority)}, priority); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Accept returns the next stream opened by the remote side, blocking until there is one.
+func (this *Mux) Accept() (*MuxStream, error) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	for len(this.pending) == 0 && this.err == nil {
+		this.acceptable.Wait()
+	}
+	if len(this.pending) == 0 {
+		return nil, this.err
+	}
+	stream := this.pending[0]
+	this.pending[0] = nil
+	this.pending = this.pending[1:]
+	return stream, nil
+}
+
+// Close closes the connection and all the streams, data not sent yet is discarded.
+func (this *Mux) Close() error {
+	this.fail(ErrMuxClosed)
+	return nil
+}
+
+// Err returns the error that closed the mux, nil while it is open.
+func (this *Mux) Err() error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.err
+}
+
+// send queues a frame at the priority for the writing goroutine.
+func (this *Mux) send(frameType byte, id uint32, payload []byte, priority ifs.Priority) error {
+	if err := this.queue.Add(muxFrame(frameType, id, payload), priority); err != nil {
+		if muxErr := this.Err(); muxErr != nil {
+			return muxErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (this *Mux) write() {
+	for {
+		frame, ok := this.queue.Next()
+		if !ok {
+			return
+		}
+		if err := Write(frame, this.conn, this.config); err != nil {
+			this.fail(err)
+			return
+		}
+	}
+}
+
+func (this *Mux) read() {
+	for {
+		data, err := Read(this.conn, this.config)
+		if err == nil {
+			err = this.handle(data)
+		}
+		if err != nil {
+			this.fail(err)
+			return
+		}
+	}
+}
+
+// handle dispatches a frame read from the connection to its stream.
+func (this *Mux) handle(data []byte) error {
+	if !IsMuxFrame(data) {
+		return fmt.Errorf("%w: not a mux frame", ErrMuxProtocol)
+	}
+	pos := len(muxMagic)
+	frameType := data[pos]
+	id := binary.BigEndian.Uint32(data[pos+1 : pos+5])
+	payload := data[muxHeaderSize:]
+
+	this.mtx.Lock()
+	stream := this.streams[id]
+	if frameType == MuxOpen {
+		defer this.mtx.Unlock()
+		// The remote side opens the ids of the other parity, see NewMux
+		if stream != nil || len(payload) != 1 || payload[0] > byte(ifs.P1) || id%2 == this.nextId%2 {
+			return fmt.Errorf("%w: invalid open of stream %d", ErrMuxProtocol, id)
+		}
+		if this.opened[1] >= MuxMaxStreams {
+			return fmt.Errorf("%w: more than %d open streams", ErrMuxProtocol, MuxMaxStreams)
+		}
+		stream = newMuxStream(this, id, ifs.Priority(payload[0]))
+		this.streams[id] = stream
+		this.opened[1]++
+		this.pending = append(this.pending, stream)
+		this.acceptable.Signal()
+		return nil
+	}
+	this.mtx.Unlock()
+	if stream == nil {
+		// The stream was closed on both sides, the frame was in flight
+		return nil
+	}
+
+	switch frameType {
+	case MuxData:
+		return stream.receive(payload)
+	case MuxWindow:
+		if len(payload) != 4 {
+			return fmt.Errorf("%w: invalid window of stream %d", ErrMuxProtocol, id)
+		}
+		stream.grant(int(binary.BigEndian.Uint32(payload)))
+	case MuxClose:
+		stream.remoteClose()
+	default:
+		return fmt.Errorf("%w: unknown type %d", ErrMuxProtocol, frameType)
+	}
+	return nil
+}
+
+// remove forgets a stream closed on both sides.
+func (this *Mux) remove(id uint32) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if _, ok := this.streams[id]; !ok {
+		return
+	}
+	delete(this.streams, id)
+	if id%2 == this.nextId%2 {
+		this.opened[0]--
+	} else {
+		this.opened[1]--
+	}
+}
+
+func (this *Mux) fail(err error) {
+	this.mtx.Lock()
+	if this.err != nil {
+		this.mtx.Unlock()
+		return
+	}
+	this.err = err
+	streams := make([]*MuxStream, 0, len(this.streams))
+	for _, stream := range this.streams {
+		streams = append(streams, stream)
+	}
+	this.acceptable.Broadcast()
+	this.mtx.Unlock()
+
+	this.queue.Close()
+	this.conn.Close()
+	for _, stream := range streams {
+		stream.fail(err)
+	}
+}
+
+// MuxStream is a stream of a Mux. It is a net.Conn, so Read, Write and the other
+// functions of this package can be used on it as on the connection.
+type MuxStream struct {
+	mux           *Mux
+	id            uint32
+	priority      ifs.Priority
+	window        int
+	received      bytes.Buffer
+	unacked       int
+	localClosed   bool
+	remoteClosed  bool
+	err           error
+	readDeadline  time.Time
+	writeDeadline time.Time
+	readTimer     *time.Timer
+	writeTimer    *time.Timer
+	cond          *sync.Cond
+	mtx           sync.Mutex
+}
+
+func newMuxStream(mux *Mux, id uint32, priority ifs.Priority) *MuxStream {
+	stream := &MuxStream{mux: mux, id: id, priority: priority, window: MuxWindowSize}
+	stream.cond = sync.NewCond(&stream.mtx)
+	return stream
+}
+
+// Id returns the stream id.
+func (this *MuxStream) Id() uint32 {
+	return this.id
+}
+
+// Priority returns the priority the stream data is sent at.
+func (this *MuxStream) Priority() ifs.Priority {
+	return this.priority
+}
+
+// Read reads the stream data, blocking until there is some.
+// Returns io.EOF once the remote side closed the stream and all its data was read.
+func (this *MuxStream) Read(data []byte) (int, error) {
+	this.mtx.Lock()
+	for this.received.Len() == 0 && !this.remoteClosed && this.readable() == nil {
+		this.cond.Wait()
+	}
+	if err := this.readable(); err != nil {
+		this.mtx.Unlock()
+		return 0, err
+	}
+	if this.received.Len() == 0 {
+		this.mtx.Unlock()
+		return 0, io.EOF
+	}
+	n, _ := this.received.Read(data)
+	this.unacked += n
+	grant := 0
+	if this.unacked >= MuxWindowSize/2 && !this.remoteClosed {
+		grant = this.unacked
+		this.unacked = 0
+	}
+	this.mtx.Unlock()
+
+	if grant > 0 {
+		// Window updates are control traffic, sent ahead of the stream data
+		this.mux.send(MuxWindow, this.id, binary.BigEndian.AppendUint32(nil, uint32(grant)), ifs.P1)
+	}
+	return n, nil
+}
+
+// Write sends the data in chunks, blocking while the stream has no window left.
+func (this *MuxStream) Write(data []byte) (int, error) {
+	written := 0
+	for written < len(data) {
+		this.mtx.Lock()
+		for this.window == 0 && this.writable() == nil {
+			this.cond.Wait()
+		}
+		if err := this.writable(); err != nil {
+			this.mtx.Unlock()
+			return written, err
+		}
+		size := min(len(data)-written, this.window, MuxChunkSize)
+		this.window -= size
+		this.mtx.Unlock()
+
+		if err := this.mux.send(MuxData, this.id, data[written:written+size], this.priority); err != nil {
+			return written, err
+		}
+		written += size
+	}
+	return written, nil
+}
+
+// Close closes the stream, the data received and not read yet is discarded.
+func (this *MuxStream) Close() error {
+	this.mtx.Lock()
+	if this.localClosed {
+		this.mtx.Unlock()
+		return nil
+	}
+	this.localClosed = true
+	this.received.Reset()
+	this.cond.Broadcast()
+	remoteClosed := this.remoteClosed
+	this.mtx.Unlock()
+
+	if remoteClosed {
+		this.mux.remove(this.id)
+	}
+	if err := this.mux.send(MuxClose, this.id, nil, this.priority); err != nil && err != ErrMuxClosed {
+		return err
+	}
+	return nil
+}
+
+// LocalAddr returns the local address of the mux connection.
+func (this *MuxStream) LocalAddr() net.Addr {
+	return this.mux.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the mux connection.
+func (this *MuxStream) RemoteAddr() net.Addr {
+	return this.mux.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the stream.
+func (this *MuxStream) SetDeadline(t time.Time) error {
+	this.SetReadDeadline(t)
+	return this.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline of Read, a zero time means no deadline.
+func (this *MuxStream) SetReadDeadline(t time.Time) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.readDeadline = t
+	this.readTimer = this.wakeAt(this.readTimer, t)
+	return nil
+}
+
+// SetWriteDeadline sets the deadline of Write waiting for window, a zero time means no deadline.
+func (this *MuxStream) SetWriteDeadline(t time.Time) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.writeDeadline = t
+	this.writeTimer = this.wakeAt(this.writeTimer, t)
+	return nil
+}
+
+// wakeAt replaces the timer with one waking the blocked Read and Write calls at the deadline.
+func (this *MuxStream) wakeAt(timer *time.Timer, t time.Time) *time.Timer {
+	if timer != nil {
+		timer.Stop()
+	}
+	if t.IsZero() {
+		return nil
+	}
+	return time.AfterFunc(time.Until(t), func() {
+		this.mtx.Lock()
+		defer this.mtx.Unlock()
+		this.cond.Broadcast()
+	})
+}
+
+// readable returns the error of a Read, must be called with the lock held.
+func (this *MuxStream) readable() error {
+	switch {
+	case this.localClosed:
+		return ifs.ErrStreamClosed
+	case this.received.Len() > 0 || this.remoteClosed:
+		return nil
+	case this.err != nil:
+		return this.err
+	case expired(this.readDeadline):
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
+
+// writable returns the error of a Write, must be called with the lock held.
+func (this *MuxStream) writable() error {
+	switch {
+	case this.localClosed || this.remoteClosed:
+		return ifs.ErrStreamClosed
+	case this.err != nil:
+		return this.err
+	case expired(this.writeDeadline):
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
+
+// receive buffers data from the remote side, which must not exceed the window.
+func (this *MuxStream) receive(data []byte) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.localClosed {
+		return nil
+	}
+	if this.received.Len()+this.unacked+len(data) > MuxWindowSize {
+		return fmt.Errorf("%w: stream %d exceeded its window", ErrMuxProtocol, this.id)
+	}
+	this.received.Write(data)
+	this.cond.Broadcast()
+	return nil
+}
+
+func (this *MuxStream) grant(size int) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.window += size
+	this.cond.Broadcast()
+}
+
+func (this *MuxStream) remoteClose() {
+	this.mtx.Lock()
+	this.remoteClosed = true
+	this.cond.Broadcast()
+	localClosed := this.localClosed
+	this.mtx.Unlock()
+	if localClosed {
+		this.mux.remove(this.id)
+	}
+}
+
+func (this *MuxStream) fail(err error) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.err = err
+	this.cond.Broadcast()
+}
+
+func expired(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
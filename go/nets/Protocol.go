@@ -19,6 +19,8 @@ limitations under the License.
 package nets
 
 import (
+	"context"
+
 	"github.com/saichler/l8types/go/ifs"
 	"github.com/saichler/l8types/go/types/l8services"
 	"github.com/saichler/l8types/go/types/l8sysconfig"
@@ -34,80 +36,126 @@ import (
 //  1. Exchange local/remote UUIDs
 //  2. Exchange force-external flags
 //  3. Exchange aliases
-//  4. Exchange service registrations
+//  4. Exchange service registrations and wire capabilities
 //  5. Exchange remote VNet information
+//
+// The negotiated wire version and features are stored in config.WireVersion
+// and config.WireFeatures. See Handshake for the single round trip handshake.
+// The handshake must complete within config.HandshakeTimeoutSeconds, and
+// failures are returned as a *HandshakeError.
 func ExecuteProtocol(conn net.Conn, config *l8sysconfig.L8SysConfig, security ifs.ISecurityProvider) error {
-	err := WriteEncrypted(conn, []byte(config.LocalUuid), config, security)
+	ctx, cancel := NewHandshakeContext(config)
+	defer cancel()
+	return ExecuteProtocolContext(ctx, conn, config, security)
+}
+
+// ExecuteProtocolContext performs ExecuteProtocol within the context, e.g. to share
+// a single deadline with the proof exchange of LegacyHandshake.
+func ExecuteProtocolContext(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider) error {
+	err := WriteHandshakeFrame(ctx, conn, config, security, "uuid", []byte(config.LocalUuid))
 	if err != nil {
-		conn.Close()
 		return err
 	}
 
-	config.RemoteUuid, err = ReadEncrypted(conn, config, security)
+	remoteUuid, err := ReadHandshakeFrame(ctx, conn, config, security, "uuid")
 	if err != nil {
-		conn.Close()
 		return err
 	}
+	config.RemoteUuid = string(remoteUuid)
+	return executeLegacyProtocol(ctx, conn, config, security)
+}
+
+// LegacyHandshake performs the handshake of the nodes that do not support Handshake,
+// e.g. after a dialer failed with ErrLegacyPeer. With a provider implementing
+// ifs.ISecurityProviderProof, the nodes first exchange their proofs, then both
+// perform ExecuteProtocol.
+func LegacyHandshake(conn net.Conn, config *l8sysconfig.L8SysConfig, security ifs.ISecurityProvider) error {
+	ctx, cancel := NewHandshakeContext(config)
+	defer cancel()
+	return LegacyHandshakeContext(ctx, conn, config, security)
+}
+
+// LegacyHandshakeContext performs LegacyHandshake within the context.
+func LegacyHandshakeContext(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider) error {
+	if prover, ok := security.(ifs.ISecurityProviderProof); ok {
+		err := WriteHandshakeFrame(ctx, conn, config, security, "secret", prover.HandshakeProof())
+		if err != nil {
+			return err
+		}
+		proof, err := ReadHandshakeFrame(ctx, conn, config, security, "secret")
+		if err != nil {
+			return err
+		}
+		if err = verifyProof(conn, security, proof); err != nil {
+			return err
+		}
+	}
+	return ExecuteProtocolContext(ctx, conn, config, security)
+}
 
+// executeLegacyProtocol performs the steps of the legacy handshake following the uuid exchange.
+func executeLegacyProtocol(ctx context.Context, conn net.Conn, config *l8sysconfig.L8SysConfig,
+	security ifs.ISecurityProvider) error {
+	config.HandshakeVersion = 0
 	forceExternal := "false"
 	if config.ForceExternal {
 		forceExternal = "true"
 	}
 
-	err = WriteEncrypted(conn, []byte(forceExternal), config, security)
+	err := WriteHandshakeFrame(ctx, conn, config, security, "forceExternal", []byte(forceExternal))
 	if err != nil {
-		conn.Close()
 		return err
 	}
 
-	forceExternal, err = ReadEncrypted(conn, config, security)
+	remoteForceExternal, err := ReadHandshakeFrame(ctx, conn, config, security, "forceExternal")
 	if err != nil {
-		conn.Close()
 		return err
 	}
-	if forceExternal == "true" {
+	if string(remoteForceExternal) == "true" {
 		config.ForceExternal = true
 	}
 
-	err = WriteEncrypted(conn, []byte(config.LocalAlias), config, security)
+	err = WriteHandshakeFrame(ctx, conn, config, security, "alias", []byte(config.LocalAlias))
 	if err != nil {
-		conn.Close()
 		return err
 	}
 
-	remoteAlias, err := ReadEncrypted(conn, config, security)
+	remoteAlias, err := ReadHandshakeFrame(ctx, conn, config, security, "alias")
 	if err != nil {
-		conn.Close()
 		return err
 	}
-	config.RemoteAlias = remoteAlias
+	config.RemoteAlias = string(remoteAlias)
 
-	err = WriteEncrypted(conn, ServicesToBytes(config.Services), config, security)
+	err = WriteHandshakeFrame(ctx, conn, config, security, "services",
+		ServicesToBytes(servicesWithCapabilities(config, security)))
 	if err != nil {
-		conn.Close()
 		return err
 	}
 
-	services, err := ReadEncryptedBytes(conn, config, security)
+	services, err := ReadHandshakeFrame(ctx, conn, config, security, "services")
 	if err != nil {
-		conn.Close()
 		return err
 	}
-	config.Services = BytesToServices(services)
+	remoteServices := BytesToServices(services)
+	applyCapabilities(config, remoteServices.GetCapabilities(), security)
+	if remoteServices != nil {
+		remoteServices.Capabilities = nil
+	}
+	config.Services = remoteServices
 
-	err = WriteEncrypted(conn, []byte(config.RemoteVnet), config, security)
+	err = WriteHandshakeFrame(ctx, conn, config, security, "vnet", []byte(config.RemoteVnet))
 	if err != nil {
-		conn.Close()
 		return err
 	}
 
-	remoteVnet, err := ReadEncrypted(conn, config, security)
+	remoteVnet, err := ReadHandshakeFrame(ctx, conn, config, security, "vnet")
 	if err != nil {
-		conn.Close()
 		return err
 	}
 	if config.RemoteVnet == "" {
-		config.RemoteVnet = remoteVnet
+		config.RemoteVnet = string(remoteVnet)
 	}
 	return nil
 }
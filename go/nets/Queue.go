@@ -0,0 +1,268 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Queue.go provides bounded priority queues of frames between a connection
+// and the VNic, one level per message priority (P1 to P8). Levels are
+// dequeued by weighted round robin, so P1 frames such as leader heartbeats
+// are not stuck behind bulk data while lower levels are never starved.
+
+package nets
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// QueueLevels is the number of priority levels of a queue, one per message priority.
+const QueueLevels = int(ifs.P1) + 1
+
+// OverflowPolicy tells a full queue what to do with a new frame.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks Add until there is room in the queue or it is closed.
+	OverflowBlock OverflowPolicy = 0
+	// OverflowReject rejects the new frame with ErrQueueFull.
+	OverflowReject OverflowPolicy = 1
+	// OverflowDropLowest drops the oldest frame of the lowest level below the new frame's
+	// priority to make room, and rejects the new frame when there is no such frame.
+	OverflowDropLowest OverflowPolicy = 2
+)
+
+var (
+	// ErrQueueFull is returned by Add when the frame was rejected because the queue is full.
+	ErrQueueFull = errors.New("queue is full")
+	// ErrQueueClosed is returned by Add after the queue was closed.
+	ErrQueueClosed = errors.New("queue is closed")
+)
+
+// DefaultQueueWeights are the frames dequeued from each level per round, indexed by priority.
+// Each priority gets twice the share of the one below it.
+var DefaultQueueWeights = [QueueLevels]int{1, 2, 4, 8, 16, 32, 64, 128}
+
+// QueueStats are the metrics of a queue level.
+type QueueStats struct {
+	Depth     int    // Frames currently in the level
+	HighWater int    // Largest depth seen
+	Enqueued  uint64 // Frames added
+	Dequeued  uint64 // Frames taken by Next or Poll
+	Dropped   uint64 // Frames rejected or dropped on overflow
+}
+
+// queueLevel holds the frames of a single priority in FIFO order.
+type queueLevel struct {
+	frames [][]byte
+	weight int
+	credit int
+	stats  QueueStats
+}
+
+// PriorityQueue is a bounded multi-level queue of frames. Safe for concurrent use.
+type PriorityQueue struct {
+	levels   [QueueLevels]*queueLevel
+	size     int
+	count    int
+	policy   OverflowPolicy
+	closed   bool
+	mtx      sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+}
+
+// NewPriorityQueue creates a queue of up to size frames in total, 0 for no limit.
+func NewPriorityQueue(size int, policy OverflowPolicy) *PriorityQueue {
+	queue := &PriorityQueue{size: size, policy: policy}
+	queue.notEmpty = sync.NewCond(&queue.mtx)
+	queue.notFull = sync.NewCond(&queue.mtx)
+	for i := range queue.levels {
+		queue.levels[i] = &queueLevel{weight: DefaultQueueWeights[i], credit: DefaultQueueWeights[i]}
+	}
+	return queue
+}
+
+// NewTxQueue creates a queue for the frames to write, sized by the config TxQueueSize.
+func NewTxQueue(config *l8sysconfig.L8SysConfig, policy OverflowPolicy) *PriorityQueue {
+	return NewPriorityQueue(int(config.TxQueueSize), policy)
+}
+
+// NewRxQueue creates a queue for the frames read, sized by the config RxQueueSize.
+func NewRxQueue(config *l8sysconfig.L8SysConfig, policy OverflowPolicy) *PriorityQueue {
+	return NewPriorityQueue(int(config.RxQueueSize), policy)
+}
+
+// SetWeight sets the frames dequeued from the priority level per round, at least 1.
+func (this *PriorityQueue) SetWeight(priority ifs.Priority, weight int) {
+	if weight < 1 {
+		weight = 1
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	level := this.levels[levelOf(priority)]
+	level.weight = weight
+	if level.credit > weight {
+		level.credit = weight
+	}
+}
+
+// AddFrame adds a marshaled message, queued by the priority in its header.
+func (this *PriorityQueue) AddFrame(data []byte) error {
+	if len(data) <= ifs.PPriority {
+		return errors.New("frame is too short for a message header")
+	}
+	priority, _ := ifs.ByteToPriorityMulticastMode(data[ifs.PPriority])
+	return this.Add(data, priority)
+}
+
+// Add adds a frame with the priority, applying the overflow policy when the queue is full.
+func (this *PriorityQueue) Add(data []byte, priority ifs.Priority) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	level := this.levels[levelOf(priority)]
+	for !this.closed && this.full() {
+		switch this.policy {
+		case OverflowBlock:
+			this.notFull.Wait()
+			continue
+		case OverflowDropLowest:
+			if this.dropBelow(levelOf(priority)) {
+				continue
+			}
+		}
+		level.stats.Dropped++
+		return ErrQueueFull
+	}
+	if this.closed {
+		return ErrQueueClosed
+	}
+	level.frames = append(level.frames, data)
+	level.stats.Enqueued++
+	level.stats.Depth++
+	if level.stats.Depth > level.stats.HighWater {
+		level.stats.HighWater = level.stats.Depth
+	}
+	this.count++
+	this.notEmpty.Signal()
+	return nil
+}
+
+// Next returns the next frame, blocking until there is one.
+// Returns false when the queue is closed and no frames are left.
+func (this *PriorityQueue) Next() ([]byte, bool) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	for this.count == 0 {
+		if this.closed {
+			return nil, false
+		}
+		this.notEmpty.Wait()
+	}
+	return this.take(), true
+}
+
+// Poll returns the next frame without blocking, false if the queue is empty.
+func (this *PriorityQueue) Poll() ([]byte, bool) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.count == 0 {
+		return nil, false
+	}
+	return this.take(), true
+}
+
+// Len returns the number of frames in the queue.
+func (this *PriorityQueue) Len() int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.count
+}
+
+// Stats returns the metrics of each level, indexed by priority.
+func (this *PriorityQueue) Stats() [QueueLevels]QueueStats {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	var stats [QueueLevels]QueueStats
+	for i, level := range this.levels {
+		stats[i] = level.stats
+	}
+	return stats
+}
+
+// Close closes the queue, waking blocked callers. Frames left can still be taken by Next and Poll.
+func (this *PriorityQueue) Close() {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.closed = true
+	this.notEmpty.Broadcast()
+	this.notFull.Broadcast()
+}
+
+func (this *PriorityQueue) full() bool {
+	return this.size > 0 && this.count >= this.size
+}
+
+// take removes the next frame by weighted round robin: the highest non-empty level
+// with credit left is served, and the credit of all levels is refilled once every
+// non-empty level used up its credit. Must be called with the lock held and count > 0.
+func (this *PriorityQueue) take() []byte {
+	for {
+		for i := QueueLevels - 1; i >= 0; i-- {
+			level := this.levels[i]
+			if len(level.frames) == 0 || level.credit == 0 {
+				continue
+			}
+			level.credit--
+			data := level.frames[0]
+			level.frames[0] = nil
+			level.frames = level.frames[1:]
+			level.stats.Depth--
+			level.stats.Dequeued++
+			this.count--
+			this.notFull.Signal()
+			return data
+		}
+		for _, level := range this.levels {
+			level.credit = level.weight
+		}
+	}
+}
+
+// dropBelow drops the oldest frame of the lowest non-empty level below the index.
+func (this *PriorityQueue) dropBelow(index int) bool {
+	for i := 0; i < index; i++ {
+		level := this.levels[i]
+		if len(level.frames) == 0 {
+			continue
+		}
+		level.frames[0] = nil
+		level.frames = level.frames[1:]
+		level.stats.Depth--
+		level.stats.Dropped++
+		this.count--
+		return true
+	}
+	return false
+}
+
+// levelOf returns the level index of the priority. Invalid priorities, which the priority
+// nibble of a malformed frame can hold, are clamped to P8 so they never jump ahead of P1.
+func levelOf(priority ifs.Priority) int {
+	if priority > ifs.P1 {
+		return int(ifs.P8)
+	}
+	return int(priority)
+}
@@ -0,0 +1,248 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// RateLimit.go provides token bucket rate limits on the messages of a connection,
+// configured per connection and per service in L8SysConfig. The limits protect the
+// receiving side, so they are enforced where frames are read rather than in Write,
+// which a misbehaving node would not call. Create a RateLimiter per accepted connection
+// and check every frame read from it with AllowFrame before it is handled. Drop the
+// frames over the limit and answer them with RateLimitReply, a fail reply carrying the
+// RateLimitError. A sending side that knows the limits of the remote side can pace
+// itself with Wait or RateLimiter.Write instead of having its messages dropped.
+
+package nets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// ErrRateLimited is returned when a message is over a rate limit.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitError is returned for a message over a rate limit. RetryAfter is the
+// backpressure signal, the time until the message would be allowed.
+type RateLimitError struct {
+	// Service is the service name of the limit, empty for the connection limit.
+	Service string
+	// RetryAfter is the time until the limit allows the message.
+	RetryAfter time.Duration
+}
+
+func (this *RateLimitError) Error() string {
+	scope := "connection"
+	if this.Service != "" {
+		scope = "service " + this.Service
+	}
+	return fmt.Sprintf("%s for %s, retry after %s", ErrRateLimited, scope, this.RetryAfter)
+}
+
+func (this *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RateLimitStats counts the messages checked by a RateLimiter.
+type RateLimitStats struct {
+	Allowed uint64
+	Dropped uint64
+}
+
+// tokenBucket holds up to burst tokens, refilled at rate tokens per second.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = rate
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// delay refills the bucket and returns the time until it has n tokens, 0 if it has.
+// A request larger than the burst only needs a full bucket, so it can still pass,
+// and take then charges its full size.
+func (this *tokenBucket) delay(n float64, now time.Time) time.Duration {
+	if this == nil {
+		return 0
+	}
+	this.tokens = min(this.burst, this.tokens+now.Sub(this.last).Seconds()*this.rate)
+	this.last = now
+	n = min(n, this.burst)
+	if this.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - this.tokens) / this.rate * float64(time.Second))
+}
+
+// take charges n tokens. The bucket goes negative for a request larger than what is
+// left, so the time until the next request is allowed includes the debt.
+func (this *tokenBucket) take(n float64) {
+	if this != nil {
+		this.tokens -= n
+	}
+}
+
+// rateLimit is the message and byte buckets of an L8RateLimit.
+type rateLimit struct {
+	service  string
+	messages *tokenBucket
+	bytes    *tokenBucket
+}
+
+func newRateLimit(service string, limit *l8sysconfig.L8RateLimit, now time.Time) *rateLimit {
+	if limit == nil || (limit.MessagesPerSecond == 0 && limit.BytesPerSecond == 0) {
+		return nil
+	}
+	return &rateLimit{
+		service:  service,
+		messages: newTokenBucket(float64(limit.MessagesPerSecond), float64(limit.MessageBurst), now),
+		bytes:    newTokenBucket(float64(limit.BytesPerSecond), float64(limit.ByteBurst), now),
+	}
+}
+
+func (this *rateLimit) delay(size int, now time.Time) time.Duration {
+	if this == nil {
+		return 0
+	}
+	return max(this.messages.delay(1, now), this.bytes.delay(float64(size), now))
+}
+
+func (this *rateLimit) take(size int) {
+	if this != nil {
+		this.messages.take(1)
+		this.bytes.take(float64(size))
+	}
+}
+
+// RateLimiter applies the rate limits of a connection. Create one per connection.
+// Safe for concurrent use.
+type RateLimiter struct {
+	connection *rateLimit
+	services   map[string]*rateLimit
+	stats      RateLimitStats
+	mtx        sync.Mutex
+}
+
+// NewRateLimiter creates a limiter with the config ConnectionRateLimit and ServiceRateLimits.
+func NewRateLimiter(config *l8sysconfig.L8SysConfig) *RateLimiter {
+	now := time.Now()
+	limiter := &RateLimiter{
+		connection: newRateLimit("", config.ConnectionRateLimit, now),
+		services:   make(map[string]*rateLimit),
+	}
+	for service, limit := range config.ServiceRateLimits {
+		if serviceLimit := newRateLimit(service, limit, now); serviceLimit != nil {
+			limiter.services[service] = serviceLimit
+		}
+	}
+	return limiter
+}
+
+// Allow takes a message of the service and size from the limits, or returns a
+// *RateLimitError without taking anything if a limit does not allow it.
+func (this *RateLimiter) Allow(serviceName string, size int) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if err := this.check(serviceName, size, time.Now()); err != nil {
+		this.stats.Dropped++
+		return err
+	}
+	this.stats.Allowed++
+	return nil
+}
+
+// AllowFrame applies Allow to a marshaled message, by the service name in its header.
+func (this *RateLimiter) AllowFrame(data []byte) error {
+	return this.Allow(ifs.ToServiceName(data), len(data))
+}
+
+// Wait blocks until the limits allow a message of the service and size, and takes it.
+// Returns the context error if the context is done first.
+func (this *RateLimiter) Wait(ctx context.Context, serviceName string, size int) error {
+	for {
+		this.mtx.Lock()
+		err := this.check(serviceName, size, time.Now())
+		if err == nil {
+			this.stats.Allowed++
+		}
+		this.mtx.Unlock()
+		limitErr, ok := err.(*RateLimitError)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(limitErr.RetryAfter)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Write waits for the limits to allow the frame, by the service name in its header, and
+// writes it to the connection. Returns the context error if the context is done first.
+func (this *RateLimiter) Write(ctx context.Context, data []byte, conn net.Conn, config *l8sysconfig.L8SysConfig) error {
+	if err := this.Wait(ctx, ifs.ToServiceName(data), len(data)); err != nil {
+		return err
+	}
+	return WriteContext(ctx, data, conn, config)
+}
+
+// RateLimitReply returns the fail reply to a message dropped for the error of AllowFrame,
+// giving the error as the reason, from the node of localUuid. Returns nil for a reply,
+// which is never answered so that two limited nodes do not answer each other forever.
+func RateLimitReply(msg *ifs.Message, err error, localUuid string) *ifs.Message {
+	if msg.Reply() {
+		return nil
+	}
+	return msg.CloneFail(err.Error(), localUuid)
+}
+
+// Stats returns the messages allowed and dropped by Allow, and allowed by Wait.
+func (this *RateLimiter) Stats() RateLimitStats {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.stats
+}
+
+// check takes the message from the service and connection limits if both allow it.
+// Must be called with the lock held.
+func (this *RateLimiter) check(serviceName string, size int, now time.Time) error {
+	service := this.services[serviceName]
+	if delay := service.delay(size, now); delay > 0 {
+		return &RateLimitError{Service: serviceName, RetryAfter: delay}
+	}
+	if delay := this.connection.delay(size, now); delay > 0 {
+		return &RateLimitError{RetryAfter: delay}
+	}
+	service.take(size)
+	this.connection.take(size)
+	return nil
+}
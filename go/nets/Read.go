@@ -19,62 +19,48 @@ limitations under the License.
 package nets
 
 import (
-	"errors"
+	"context"
 
 	"github.com/saichler/l8types/go/ifs"
 	"github.com/saichler/l8types/go/types/l8sysconfig"
 
 	"net"
-	"time"
 )
 
-// Read data from socket
+// Read data from socket, see ReadContext for reading within a context
 func Read(conn net.Conn, config *l8sysconfig.L8SysConfig) ([]byte, error) {
-	// If the connection is nil, return an error
-	if conn == nil {
-		return nil, errors.New("no Connection Available")
-	}
-	// If the config is nil, error
-	if config == nil {
-		return nil, errors.New("no Config Available")
-	}
-	// read 8 bytes, e.g. long, hinting of the size of the byte array
-	sizebytes, err := ReadSize(8, conn, config)
-	if sizebytes == nil || err != nil {
+	return readFrame(context.Background(), conn, config, func(size int) []byte {
+		return make([]byte, size)
+	})
+}
+
+// ReadMessage reads a message frame and unmarshals it, see UnmarshalMessage.
+func ReadMessage(conn net.Conn, config *l8sysconfig.L8SysConfig, resources ifs.IResources) (*ifs.Message, error) {
+	data, err := Read(conn, config)
+	if err != nil {
 		return nil, err
 	}
-	// Translate the 8 byte array into int64
-	size := ifs.Bytes2Long(sizebytes)
-	// If the size is larger than the MAX Data Size, return an error
-	// this is to protect against overflowing the buffers
-	// When data to send is > the max data size, one needs to split the data into chunks at a higher level
-	if uint64(size) > config.MaxDataSize {
-		return nil, errors.New("Max Size Exceeded!")
+	return UnmarshalMessage(data, config, resources)
+}
+
+// UnmarshalMessage unmarshals a message frame read from the connection of the config.
+// Frames below the wire version negotiated by the handshake, config.WireVersion, are
+// rejected with ifs.ErrMessageVersion, so a frame downgraded on the path to a version
+// without authenticated encryption is never accepted.
+func UnmarshalMessage(data []byte, config *l8sysconfig.L8SysConfig, resources ifs.IResources) (*ifs.Message, error) {
+	msg := &ifs.Message{}
+	if _, err := msg.UnmarshalMinVersion(data, resources, byte(config.WireVersion)); err != nil {
+		return nil, err
 	}
-	// Read the bunch of bytes according to the size from the socket
-	data, err := ReadSize(int(size), conn, config)
-	return data, err
+	return msg, nil
 }
 
 // ReadSize reads exactly 'size' bytes from the connection, handling partial reads.
 // Will retry reads until all bytes are received or an error occurs.
 func ReadSize(size int, conn net.Conn, config *l8sysconfig.L8SysConfig) ([]byte, error) {
 	data := make([]byte, size)
-	n, e := conn.Read(data)
-	if e != nil {
-		return nil, errors.New("Failed to read data size:" + e.Error())
-	}
-
-	if n < size {
-		if n == 0 {
-			time.Sleep(time.Second)
-		}
-		data = data[0:n]
-		left, e := ReadSize(size-n, conn, config)
-		if e != nil {
-			return nil, errors.New("Failed to read packet size:" + e.Error())
-		}
-		data = append(data, left...)
+	if err := readFull(context.Background(), conn, data); err != nil {
+		return nil, err
 	}
 	return data, nil
 }
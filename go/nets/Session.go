@@ -0,0 +1,410 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Session.go provides session resumption for fast reconnects. The acceptor issues
+// a session ticket in the L8HelloAck of the handshake. After a transient disconnect,
+// the dialer presents the ticket in its L8Hello to resume the session, and both
+// sides resend the frames the other side did not receive.
+// Frames are written and read through the Session, which keeps the frames sent until
+// the remote side acknowledges them. Ack frames start with a marker that a message
+// header never starts with: marker, 8 bytes frames received.
+
+package nets
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// HelloFeatureSessions is the L8Hello feature of nodes supporting session tickets.
+const HelloFeatureSessions uint64 = 1 << 0
+
+const (
+	// DefaultSessionTicketTTL is the time a session can be resumed after its connection
+	// failed when the config does not set one.
+	DefaultSessionTicketTTL = 5 * time.Minute
+	// SessionAckInterval is the number of frames received between two ack frames.
+	SessionAckInterval = 32
+	// SessionBufferSize is the number of frames kept for the remote side to acknowledge.
+	// When it is exceeded the oldest frames are dropped and the session cannot be resumed.
+	SessionBufferSize = 4096
+)
+
+// ErrSessionGap is returned when resuming a session whose frames the remote side
+// did not receive are no longer kept. A new session should be started.
+var ErrSessionGap = errors.New("session frames were lost, start a new session")
+
+// ackMagic starts every ack frame. Message frames start with the source
+// uuid, which never contains a NUL byte.
+var ackMagic = []byte("\x00L8ACK")
+
+// ackFrameSize is the size of an ack frame.
+var ackFrameSize = len(ackMagic) + 8
+
+func isAckFrame(data []byte) bool {
+	return len(data) == ackFrameSize && bytes.HasPrefix(data, ackMagic)
+}
+
+// DialSession performs Handshake on the dialer side within a session. With the session
+// of a previous connection, the dialer asks to resume it, and on success the same session
+// is returned after resending the frames the acceptor did not receive. Otherwise a new
+// session is returned, the frames of the previous session are then not resent, see
+// Session.Unacknowledged.
+func DialSession(conn net.Conn, config *l8sysconfig.L8SysConfig, security ifs.ISecurityProvider,
+	session *Session) (*Session, error) {
+	ctx, cancel := NewHandshakeContext(config)
+	defer cancel()
+	hello := localHello(config, security, HandshakeVersion)
+	hello.Features |= HelloFeatureSessions
+	if session != nil && session.ticket != "" {
+		hello.SessionTicket = session.ticket
+		hello.Received = session.Received()
+	}
+	ack, err := dialHello(ctx, conn, config, security, hello)
+	if err != nil {
+		return nil, err
+	}
+	if ack.Resumed && hello.SessionTicket != "" {
+		if err = session.resume(conn, config, ack.Hello.Received); err != nil {
+			return nil, err
+		}
+		return session, nil
+	}
+	return newSession(ack.Hello.SessionTicket, nil, conn, config), nil
+}
+
+// AcceptSession performs Handshake on the acceptor side within a session. A dialer
+// presenting the ticket of a session in the cache resumes it, and the frames it did not
+// receive are resent. Otherwise a new session is issued and added to the cache.
+// The session of a dialer that does not support sessions cannot be resumed.
+func AcceptSession(conn net.Conn, config *l8sysconfig.L8SysConfig, security ifs.ISecurityProvider,
+	cache *SessionCache) (*Session, error) {
+	ctx, cancel := NewHandshakeContext(config)
+	defer cancel()
+	var session *Session
+	resumed := false
+	hello, err := acceptHello(ctx, conn, config, security, func(hello *l8sysconfig.L8Hello, ack *l8sysconfig.L8HelloAck) {
+		if hello.Features&HelloFeatureSessions == 0 {
+			return
+		}
+		ack.Hello.Features |= HelloFeatureSessions
+		if session = cache.resume(hello.SessionTicket, hello.Uuid, hello.Received); session != nil {
+			resumed = true
+			ack.Resumed = true
+			ack.Hello.Received = session.Received()
+		} else {
+			session = cache.issue(hello.Uuid)
+		}
+		ack.Hello.SessionTicket = session.ticket
+	})
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return newSession("", nil, conn, config), nil
+	}
+	if resumed {
+		if err = session.resume(conn, config, hello.Received); err != nil {
+			return nil, err
+		}
+		return session, nil
+	}
+	session.attach(conn, config)
+	return session, nil
+}
+
+// SessionCache holds the sessions issued by an acceptor until they expire. Safe for concurrent use.
+type SessionCache struct {
+	ttl      time.Duration
+	sessions map[string]*Session
+	mtx      sync.Mutex
+}
+
+// NewSessionCache creates a cache of sessions expiring the config SessionTicketTtlSeconds after
+// their connection failed. A session whose connection is alive does not expire, however idle.
+func NewSessionCache(config *l8sysconfig.L8SysConfig) *SessionCache {
+	ttl := DefaultSessionTicketTTL
+	if config.SessionTicketTtlSeconds > 0 {
+		ttl = time.Duration(config.SessionTicketTtlSeconds) * time.Second
+	}
+	return &SessionCache{ttl: ttl, sessions: make(map[string]*Session)}
+}
+
+// Len returns the number of sessions in the cache, including expired ones not purged yet.
+func (this *SessionCache) Len() int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return len(this.sessions)
+}
+
+// Remove removes the session of the ticket, it can no longer be resumed.
+func (this *SessionCache) Remove(ticket string) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	delete(this.sessions, ticket)
+}
+
+func (this *SessionCache) issue(remoteUuid string) *Session {
+	session := newSession(ifs.NewUuid(), this, nil, nil)
+	session.remoteUuid = remoteUuid
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.purge(time.Now())
+	this.sessions[session.ticket] = session
+	return session
+}
+
+// resume returns the session of the ticket if it belongs to the remote uuid, did not
+// expire and still has the frames the remote side did not receive.
+func (this *SessionCache) resume(ticket, remoteUuid string, received uint64) *Session {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.purge(time.Now())
+	session, ok := this.sessions[ticket]
+	if !ok || session.remoteUuid != remoteUuid || !session.canResume(received) {
+		return nil
+	}
+	return session
+}
+
+// purge removes the expired sessions, must be called with the lock held.
+func (this *SessionCache) purge(now time.Time) {
+	for key, session := range this.sessions {
+		if session.detachedFor(now) > this.ttl {
+			delete(this.sessions, key)
+		}
+	}
+}
+
+// Session is a sequence of frames exchanged with a remote node over one or more
+// connections. Safe for concurrent use, with a single goroutine calling Read.
+type Session struct {
+	ticket     string
+	remoteUuid string
+	cache      *SessionCache
+	conn       net.Conn
+	config     *l8sysconfig.L8SysConfig
+	sent       [][]byte
+	sentCount  uint64
+	received   uint64
+	unacked    int
+	ackDue     bool
+	detached   time.Time
+	writeMtx   sync.Mutex
+	mtx        sync.Mutex
+}
+
+func newSession(ticket string, cache *SessionCache, conn net.Conn, config *l8sysconfig.L8SysConfig) *Session {
+	session := &Session{ticket: ticket, cache: cache, conn: conn, config: config}
+	if conn == nil {
+		session.detached = time.Now()
+	}
+	return session
+}
+
+// Ticket returns the session ticket, empty if the remote node does not support sessions.
+func (this *Session) Ticket() string {
+	return this.ticket
+}
+
+// Conn returns the current connection of the session.
+func (this *Session) Conn() net.Conn {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.conn
+}
+
+// Received returns the number of frames received in the session.
+func (this *Session) Received() uint64 {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.received
+}
+
+// Unacknowledged returns the frames sent that the remote side did not acknowledge yet,
+// e.g. to send them on a new session when this one could not be resumed.
+func (this *Session) Unacknowledged() [][]byte {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return append([][]byte(nil), this.sent...)
+}
+
+// Write writes a frame with Write on the current connection. The frame is kept until
+// the remote side acknowledges it, so it is resent on resume if the write fails.
+func (this *Session) Write(data []byte) error {
+	this.writeMtx.Lock()
+	defer this.writeMtx.Unlock()
+	this.mtx.Lock()
+	this.sent = append(this.sent, append([]byte(nil), data...))
+	this.sentCount++
+	if len(this.sent) > SessionBufferSize {
+		this.sent[0] = nil
+		this.sent = this.sent[1:]
+	}
+	conn, config := this.conn, this.config
+	this.mtx.Unlock()
+
+	if err := Write(data, conn, config); err != nil {
+		this.detach(conn)
+		return err
+	}
+	this.writeAck()
+	return nil
+}
+
+// Read reads the next frame with Read from the current connection, handling the ack frames.
+func (this *Session) Read() ([]byte, error) {
+	for {
+		conn, config := this.current()
+		data, err := Read(conn, config)
+		if err != nil {
+			this.detach(conn)
+			return nil, err
+		}
+		if isAckFrame(data) {
+			this.acknowledge(uint64(ifs.Bytes2Long(data[len(ackMagic):])))
+			continue
+		}
+		this.mtx.Lock()
+		this.received++
+		this.unacked++
+		if this.unacked >= SessionAckInterval {
+			this.ackDue = true
+		}
+		ackDue := this.ackDue
+		this.mtx.Unlock()
+		// The ack is left to the writing goroutine rather than waiting for it
+		if ackDue && this.writeMtx.TryLock() {
+			this.writeAck()
+			this.writeMtx.Unlock()
+		}
+		return data, nil
+	}
+}
+
+// Close closes the connection, the session can no longer be resumed.
+func (this *Session) Close() error {
+	if this.cache != nil {
+		this.cache.Remove(this.ticket)
+	}
+	conn := this.Conn()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+// current returns the connection and config the session currently uses.
+func (this *Session) current() (net.Conn, *l8sysconfig.L8SysConfig) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.conn, this.config
+}
+
+// writeAck writes an ack frame if one is due, must be called with the write lock held.
+// A failed write is not reported, the reader of the connection sees the failure.
+func (this *Session) writeAck() {
+	this.mtx.Lock()
+	if !this.ackDue {
+		this.mtx.Unlock()
+		return
+	}
+	this.ackDue = false
+	this.unacked = 0
+	frame := append(append(make([]byte, 0, ackFrameSize), ackMagic...), ifs.Long2Bytes(int64(this.received))...)
+	conn, config := this.conn, this.config
+	this.mtx.Unlock()
+	Write(frame, conn, config)
+}
+
+// acknowledge drops the frames the remote side received.
+func (this *Session) acknowledge(received uint64) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	base := this.sentCount - uint64(len(this.sent))
+	if received <= base {
+		return
+	}
+	drop := min(received-base, uint64(len(this.sent)))
+	clear(this.sent[:drop])
+	this.sent = this.sent[drop:]
+}
+
+// canResume returns true if the session still has the frames after the received ones.
+func (this *Session) canResume(received uint64) bool {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return received >= this.sentCount-uint64(len(this.sent)) && received <= this.sentCount
+}
+
+// detachedFor returns the time since the connection of the session failed, 0 while it is attached.
+func (this *Session) detachedFor(now time.Time) time.Duration {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.detached.IsZero() {
+		return 0
+	}
+	return now.Sub(this.detached)
+}
+
+// detach records that the connection failed, unless the session already moved to another one.
+func (this *Session) detach(conn net.Conn) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.conn == conn && this.detached.IsZero() {
+		this.detached = time.Now()
+	}
+}
+
+// attach sets the connection of the session, closing the previous one.
+func (this *Session) attach(conn net.Conn, config *l8sysconfig.L8SysConfig) {
+	this.mtx.Lock()
+	previous := this.conn
+	this.conn = conn
+	this.config = config
+	this.detached = time.Time{}
+	this.mtx.Unlock()
+	if previous != nil && previous != conn {
+		previous.Close()
+	}
+}
+
+// resume moves the session to the connection and resends the frames after the ones
+// the remote side received.
+func (this *Session) resume(conn net.Conn, config *l8sysconfig.L8SysConfig, received uint64) error {
+	this.writeMtx.Lock()
+	defer this.writeMtx.Unlock()
+	if !this.canResume(received) {
+		conn.Close()
+		return NewHandshakeError("resume", ErrSessionGap, nil)
+	}
+	this.acknowledge(received)
+	this.attach(conn, config)
+	ctx, cancel := NewHandshakeContext(config)
+	defer cancel()
+	for _, frame := range this.Unacknowledged() {
+		if err := WriteContext(ctx, frame, conn, config); err != nil {
+			conn.Close()
+			return ioHandshakeError("resume", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nets
+
+import (
+	"errors"
+	"net"
+)
+
+// peerCredentials is only supported on Linux, other platforms cannot authenticate Unix socket peers.
+func peerCredentials(conn *net.UnixConn) (*UnixCredentials, error) {
+	return nil, errors.New("unix socket peer credentials are not supported on this platform")
+}
@@ -0,0 +1,115 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// UnixSocket.go provides the Unix domain socket transport for nodes on the same host.
+// It is selected by setting L8SysConfig.UnixSocketPath, and uses the same framing
+// and handshake as TCP. Peers are authenticated with the socket credentials of
+// the remote process, which must run as this user or as one of UnixSocketUids.
+
+package nets
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// ErrUnixPeerCredentials is returned when the process on the other side of a Unix socket
+// does not run as an allowed user, or its credentials cannot be read.
+var ErrUnixPeerCredentials = errors.New("unix socket peer is not an allowed user")
+
+// UnixCredentials are the credentials of the process on the other side of a Unix socket.
+type UnixCredentials struct {
+	Pid int32
+	Uid uint32
+	Gid uint32
+}
+
+// UsesUnixSocket returns true if the config selects the Unix domain socket transport.
+func UsesUnixSocket(config *l8sysconfig.L8SysConfig) bool {
+	return config.GetUnixSocketPath() != ""
+}
+
+// ListenUnix listens on the config Unix socket path, replacing a stale socket file.
+// The socket file is only accessible to this user unless other users are allowed.
+func ListenUnix(config *l8sysconfig.L8SysConfig) (net.Listener, error) {
+	if !UsesUnixSocket(config) {
+		return nil, errors.New("no Unix socket path in config")
+	}
+	path := config.UnixSocketPath
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	mode := os.FileMode(0600)
+	if len(config.UnixSocketUids) > 0 {
+		mode = 0666
+	}
+	if err = os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// DialUnix connects to the config Unix socket path.
+func DialUnix(config *l8sysconfig.L8SysConfig) (net.Conn, error) {
+	if !UsesUnixSocket(config) {
+		return nil, errors.New("no Unix socket path in config")
+	}
+	return net.Dial("unix", config.UnixSocketPath)
+}
+
+// AuthenticateUnix checks the credentials of the process on the other side of a Unix socket
+// against the allowed users of the config, returning a *HandshakeError if it is not allowed.
+func AuthenticateUnix(conn net.Conn, config *l8sysconfig.L8SysConfig) error {
+	credentials, err := PeerCredentials(conn)
+	if err != nil {
+		return NewHandshakeError("credentials", ErrUnixPeerCredentials, err)
+	}
+	if credentials.Uid == uint32(os.Getuid()) {
+		return nil
+	}
+	for _, uid := range config.GetUnixSocketUids() {
+		if credentials.Uid == uid {
+			return nil
+		}
+	}
+	return NewHandshakeError("credentials", ErrUnixPeerCredentials,
+		errors.New("uid "+strconv.Itoa(int(credentials.Uid))+" of pid "+strconv.Itoa(int(credentials.Pid))))
+}
+
+// IsUnixConn returns true if the connection is a Unix socket connection, also when it is
+// wrapped by decorators with a NetConn method, such as CaptureConn and NewFaultConn.
+func IsUnixConn(conn net.Conn) bool {
+	_, ok := unwrapConn[*net.UnixConn](conn)
+	return ok
+}
+
+// PeerCredentials returns the credentials of the process on the other side of a Unix socket,
+// see IsUnixConn for wrapped connections.
+func PeerCredentials(conn net.Conn) (*UnixCredentials, error) {
+	unixConn, ok := unwrapConn[*net.UnixConn](conn)
+	if !ok {
+		return nil, errors.New("not a Unix socket connection")
+	}
+	return peerCredentials(unixConn)
+}
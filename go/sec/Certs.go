@@ -15,12 +15,15 @@ package sec
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/pem"
+	"errors"
 	"math/big"
 	"net"
 	"time"
@@ -125,3 +128,85 @@ func localIPs() []net.IP {
 	}
 	return ips
 }
+
+// CreateCA creates a certificate authority for signing node certificates with CreateNodeCert.
+// Returns the base64 encoded PEM certificate and private key.
+func CreateCA() (string, string, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	if err != nil {
+		return "", "", err
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Layer8 CA", Organization: []string{"Layer8"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	caData, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		return "", "", err
+	}
+	return encodeCertAndKey(caData, caKey)
+}
+
+// CreateNodeCert creates a certificate for the node uuid, signed by the CA from CreateCA,
+// for mutual TLS between VNics. The uuid is the certificate common name.
+// Returns the base64 encoded PEM certificate and private key.
+func CreateNodeCert(caCert, caKey, uuid string) (string, string, error) {
+	pair, err := decodeKeyPair(caCert, caKey)
+	if err != nil {
+		return "", "", err
+	}
+	ca, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return "", "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	if err != nil {
+		return "", "", err
+	}
+	crt := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: uuid, Organization: []string{"Layer8"}},
+		IPAddresses:  localIPs(),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	crtKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	crtData, err := x509.CreateCertificate(rand.Reader, crt, ca, &crtKey.PublicKey, pair.PrivateKey)
+	if err != nil {
+		return "", "", err
+	}
+	return encodeCertAndKey(crtData, crtKey)
+}
+
+func encodeCertAndKey(certData []byte, key *ecdsa.PrivateKey) (string, string, error) {
+	keyData, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certData})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyData})
+	return base64.StdEncoding.EncodeToString(certPEM), base64.StdEncoding.EncodeToString(keyPEM), nil
+}
+
+// decodePEM decodes a base64 encoded PEM, as returned by CreateCertBundle and CreateNodeCert.
+func decodePEM(data string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, errors.New("invalid base64 PEM: " + err.Error())
+	}
+	return decoded, nil
+}
@@ -2,6 +2,7 @@ package sec
 
 import (
 	"crypto/md5"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"github.com/saichler/l8types/go/aes"
@@ -14,20 +15,31 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // ShallowSecurityProvider implements ISecurityProvider with basic AES encryption.
 // Uses a hardcoded secret for key derivation - suitable for testing only.
 type ShallowSecurityProvider struct {
-	secret string
-	key    string
+	secret     string
+	key        string
+	transport  *TLSTransport
+	unixConfig *l8sysconfig.L8SysConfig
+	// accepted holds the connections allowed by CanAccept, validated as the acceptor
+	accepted sync.Map
+	// legacyPeers holds the addresses of the acceptors that only support the legacy handshake
+	legacyPeers sync.Map
 }
 
 // NewShallowSecurityProvider creates a new provider with a hardcoded secret.
 func NewShallowSecurityProvider() *ShallowSecurityProvider {
+	return NewShallowSecurityProviderWithSecret("Shallow Security Provider")
+}
+
+// NewShallowSecurityProviderWithSecret creates a new provider deriving its key from the secret.
+func NewShallowSecurityProviderWithSecret(secret string) *ShallowSecurityProvider {
 	sp := &ShallowSecurityProvider{}
 	hash := md5.New()
-	secret := "Shallow Security Provider"
 	hash.Write([]byte(secret))
 	kHash := hash.Sum(nil)
 	sp.key = base64.StdEncoding.EncodeToString(kHash)
@@ -35,39 +47,120 @@ func NewShallowSecurityProvider() *ShallowSecurityProvider {
 	return sp
 }
 
-// CanDial establishes a TCP connection to the specified host and port.
+// NewShallowSecurityProviderWithKey creates a new provider with a base64 encoded AES key,
+// e.g. to decode frames captured from nodes using that key.
+func NewShallowSecurityProviderWithKey(key string) *ShallowSecurityProvider {
+	return &ShallowSecurityProvider{key: key}
+}
+
+// NewShallowSecurityProviderWithTLS creates a new provider deriving its key from the secret,
+// whose connections use the mutual TLS transport.
+func NewShallowSecurityProviderWithTLS(secret string, transport *TLSTransport) *ShallowSecurityProvider {
+	sp := NewShallowSecurityProviderWithSecret(secret)
+	sp.transport = transport
+	return sp
+}
+
+// NewShallowSecurityProviderWithUnixSocket creates a new provider deriving its key from the secret,
+// dialing the nodes on this host over the Unix socket when the config selects it, see nets.UsesUnixSocket.
+func NewShallowSecurityProviderWithUnixSocket(secret string, config *l8sysconfig.L8SysConfig) *ShallowSecurityProvider {
+	sp := NewShallowSecurityProviderWithSecret(secret)
+	sp.unixConfig = config
+	return sp
+}
+
+// CanDial establishes a TCP connection to the specified host and port,
+// over mutual TLS when the provider has a TLS transport. A loopback host is
+// dialed over the Unix socket of the provider config when it selects one.
 func (this *ShallowSecurityProvider) CanDial(host string, port uint32) (net.Conn, error) {
+	if nets.UsesUnixSocket(this.unixConfig) && isLoopback(host) {
+		return nets.DialUnix(this.unixConfig)
+	}
+	if this.transport != nil {
+		return this.transport.Dial(host, port)
+	}
 	if strings.Contains(host, ":") {
 		host = "[" + host + "]"
 	}
 	return net.Dial("tcp", host+":"+strconv.Itoa(int(port)))
 }
 
-// CanAccept always allows incoming connections (permissive).
+// CanAccept always allows incoming connections (permissive). With a TLS transport, the
+// connection must come from its listener and present a certificate signed by the CA.
+// ValidateConnection then validates the connection as its acceptor.
 func (this *ShallowSecurityProvider) CanAccept(conn net.Conn) error {
+	if this.transport != nil {
+		if err := this.transport.Accept(conn); err != nil {
+			return err
+		}
+	}
+	this.accepted.Store(conn, true)
 	return nil
 }
 
-// ValidateConnection verifies the connection by exchanging encrypted secrets.
+// ValidateConnection verifies the connection with the single round trip handshake of
+// nets.Handshake, proving the secret in the encrypted hello. The connection is validated
+// as its acceptor when it was allowed by CanAccept, otherwise as its dialer, see
+// ValidateConnectionRole.
 func (this *ShallowSecurityProvider) ValidateConnection(conn net.Conn, config *l8sysconfig.L8SysConfig) error {
-	err := nets.WriteEncrypted(conn, []byte(this.secret), config, this)
-	if err != nil {
-		conn.Close()
+	_, accepted := this.accepted.LoadAndDelete(conn)
+	return this.ValidateConnectionRole(conn, config, !accepted)
+}
+
+// ValidateConnectionRole verifies the connection with nets.Handshake, which must complete
+// within the config handshake timeout. An acceptor completes the legacy handshake for a legacy
+// dialer. A dialer fails with nets.ErrLegacyPeer for a legacy acceptor, and uses
+// nets.LegacyHandshake when it redials it. With a TLS transport, the certificate identities
+// must match the local and remote uuids. Over a Unix socket, the remote process must run as
+// an allowed user, see nets.AuthenticateUnix.
+func (this *ShallowSecurityProvider) ValidateConnectionRole(conn net.Conn, config *l8sysconfig.L8SysConfig, dialer bool) error {
+	if nets.IsUnixConn(conn) {
+		if err := nets.AuthenticateUnix(conn, config); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	ctx, cancel := nets.NewHandshakeContext(config)
+	defer cancel()
+	var err error
+	if _, legacy := this.legacyPeers.Load(conn.RemoteAddr().String()); dialer && legacy {
+		err = nets.LegacyHandshakeContext(ctx, conn, config, this)
+	} else {
+		err = nets.HandshakeContext(ctx, conn, config, this, dialer)
+		if dialer && errors.Is(err, nets.ErrLegacyPeer) {
+			this.legacyPeers.Store(conn.RemoteAddr().String(), true)
+		}
+	}
+	if err != nil || this.transport == nil {
 		return err
 	}
-
-	secret, err := nets.ReadEncrypted(conn, config, this)
-	if err != nil {
+	if err = this.transport.VerifyIdentity(conn, config); err != nil {
 		conn.Close()
 		return err
 	}
+	return nil
+}
 
-	if this.secret != secret {
-		conn.Close()
-		return errors.New("incorrect Secret/Key, aborting connection")
+// HandshakeProof returns the secret, carried encrypted in the handshake.
+func (this *ShallowSecurityProvider) HandshakeProof() []byte {
+	return []byte(this.secret)
+}
+
+// VerifyHandshakeProof returns an error if the remote node uses a different secret.
+func (this *ShallowSecurityProvider) VerifyHandshakeProof(proof []byte) error {
+	if subtle.ConstantTimeCompare(proof, []byte(this.secret)) != 1 {
+		return nets.ErrHandshakeBadSecret
 	}
+	return nil
+}
 
-	return nets.ExecuteProtocol(conn, config, this)
+// isLoopback returns true if the host is this host.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 // Encrypt encrypts data using AES with the derived key.
@@ -80,6 +173,16 @@ func (this *ShallowSecurityProvider) Decrypt(data string) ([]byte, error) {
 	return aes.Decrypt(data, this.key)
 }
 
+// EncryptAEAD encrypts data using AES-GCM, authenticating the additional data.
+func (this *ShallowSecurityProvider) EncryptAEAD(data, additionalData []byte) (string, error) {
+	return aes.EncryptAEAD(data, additionalData, this.key)
+}
+
+// DecryptAEAD decrypts AES-GCM data, verifying the additional data.
+func (this *ShallowSecurityProvider) DecryptAEAD(data string, additionalData []byte) ([]byte, error) {
+	return aes.DecryptAEAD(data, additionalData, this.key)
+}
+
 // CanDoAction always permits any action (permissive authorization).
 func (this *ShallowSecurityProvider) CanDoAction(vnic ifs.IVNic, action ifs.Action, o ifs.IElements, uuid string, token string, salts ...string) error {
 	return nil
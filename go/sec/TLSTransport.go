@@ -0,0 +1,205 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sec
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// ErrPeerIdentity is returned when the certificate of a TLS connection does not match the node uuid.
+var ErrPeerIdentity = errors.New("certificate identity does not match the node uuid")
+
+// TLSTransport dials and accepts VNic connections over mutual TLS. Both sides present
+// a node certificate from CreateNodeCert, verified against the CA, and the certificate
+// common name is the node uuid. The handshake of ValidateConnection runs on top of the
+// TLS connection.
+type TLSTransport struct {
+	certificate tls.Certificate
+	roots       *x509.CertPool
+	uuid        string
+	config      *l8sysconfig.L8SysConfig
+}
+
+// NewTLSTransport creates a transport from the base64 encoded PEM node certificate,
+// its private key and the CA certificate. TLS handshakes use DefaultHandshakeTimeout.
+func NewTLSTransport(cert, key, caCert string) (*TLSTransport, error) {
+	return NewTLSTransportWithConfig(cert, key, caCert, nil)
+}
+
+// NewTLSTransportWithConfig creates a transport like NewTLSTransport, whose TLS handshakes
+// must complete within the config HandshakeTimeoutSeconds.
+func NewTLSTransportWithConfig(cert, key, caCert string, config *l8sysconfig.L8SysConfig) (*TLSTransport, error) {
+	certificate, err := decodeKeyPair(cert, key)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	caPEM, err := decodePEM(caCert)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("no CA certificate found")
+	}
+	return &TLSTransport{certificate: certificate, roots: roots, uuid: leaf.Subject.CommonName, config: config}, nil
+}
+
+// Uuid returns the node uuid of the transport certificate.
+func (this *TLSTransport) Uuid() string {
+	return this.uuid
+}
+
+// Dial connects to the host and port and completes the TLS handshake within the handshake timeout.
+func (this *TLSTransport) Dial(host string, port uint32) (net.Conn, error) {
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	ctx, cancel := nets.NewHandshakeContext(this.config)
+	defer cancel()
+	dialer := &tls.Dialer{Config: this.clientConfig()}
+	return dialer.DialContext(ctx, "tcp", host+":"+strconv.Itoa(int(port)))
+}
+
+// Listen listens on the port, the accepted connections are TLS connections to pass to Accept.
+func (this *TLSTransport) Listen(port uint32) (net.Listener, error) {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(int(port)))
+	if err != nil {
+		return nil, err
+	}
+	return this.WrapListener(listener), nil
+}
+
+// WrapListener returns the listener with the accepted connections wrapped in TLS.
+func (this *TLSTransport) WrapListener(listener net.Listener) net.Listener {
+	return tls.NewListener(listener, this.serverConfig())
+}
+
+// Accept completes the TLS handshake of a connection accepted by a listener from Listen
+// or WrapListener, verifying the client certificate, within the handshake timeout.
+// The connection can be wrapped by decorators, see PeerUuid.
+func (this *TLSTransport) Accept(conn net.Conn) error {
+	tlsConn, ok := unwrapTLS(conn)
+	if !ok {
+		return errors.New("connection was not accepted by a TLS listener")
+	}
+	ctx, cancel := nets.NewHandshakeContext(this.config)
+	defer cancel()
+	return tlsConn.HandshakeContext(ctx)
+}
+
+// VerifyIdentity checks that the transport certificate is of the config LocalUuid, and
+// that the certificate of the remote side is of the config RemoteUuid, set by the handshake.
+func (this *TLSTransport) VerifyIdentity(conn net.Conn, config *l8sysconfig.L8SysConfig) error {
+	if this.uuid != config.LocalUuid {
+		return nets.NewHandshakeError("identity", ErrPeerIdentity,
+			errors.New("local certificate is of "+this.uuid+" and not of "+config.LocalUuid))
+	}
+	peer, err := PeerUuid(conn)
+	if err != nil {
+		return nets.NewHandshakeError("identity", ErrPeerIdentity, err)
+	}
+	if peer != config.RemoteUuid {
+		return nets.NewHandshakeError("identity", ErrPeerIdentity,
+			errors.New("remote certificate is of "+peer+" and not of "+config.RemoteUuid))
+	}
+	return nil
+}
+
+// PeerUuid returns the node uuid of the certificate presented by the remote side of a TLS connection,
+// also when it is wrapped by decorators with a NetConn method, such as nets.CaptureConn and nets.NewFaultConn.
+func PeerUuid(conn net.Conn) (string, error) {
+	tlsConn, ok := unwrapTLS(conn)
+	if !ok {
+		return "", errors.New("not a TLS connection")
+	}
+	state := tlsConn.ConnectionState()
+	if !state.HandshakeComplete || len(state.PeerCertificates) == 0 {
+		return "", errors.New("no peer certificate")
+	}
+	return state.PeerCertificates[0].Subject.CommonName, nil
+}
+
+// unwrapTLS returns the TLS connection, following the NetConn method of decorators.
+func unwrapTLS(conn net.Conn) (*tls.Conn, bool) {
+	for conn != nil {
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			return tlsConn, true
+		}
+		wrapper, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		conn = wrapper.NetConn()
+	}
+	return nil, false
+}
+
+func (this *TLSTransport) serverConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{this.certificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    this.roots,
+		MinVersion:   tls.VersionTLS13,
+	}
+}
+
+// clientConfig verifies the server certificate against the CA without a host name check,
+// as nodes are dialed by address and identified by the uuid in their certificate.
+func (this *TLSTransport) clientConfig() *tls.Config {
+	return &tls.Config{
+		Certificates:       []tls.Certificate{this.certificate},
+		MinVersion:         tls.VersionTLS13,
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("no server certificate")
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         this.roots,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			return err
+		},
+	}
+}
+
+// decodeKeyPair decodes a base64 encoded PEM certificate and private key.
+func decodeKeyPair(cert, key string) (tls.Certificate, error) {
+	certPEM, err := decodePEM(cert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := decodePEM(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
@@ -0,0 +1,175 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simnet simulates a network of Layer 8 nodes in memory for tests.
+// Nodes are connected over in-memory pipes instead of TCP ports, validate each
+// connection with ShallowSecurityProvider and the single round trip handshake
+// of nets.Handshake like real nodes do, and route messages by destination, so
+// multi-node tests run in milliseconds.
+package simnet
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/types/l8services"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// DefaultMaxDataSize is the max frame size of the simulated nodes.
+const DefaultMaxDataSize = 1024 * 1024
+
+// Route is a message received over a link, reported to the OnRoute hooks before
+// the receiving node delivers or forwards it.
+type Route struct {
+	// From is the uuid of the node that sent the message over the link.
+	From string
+	// To is the uuid of the node that received the message.
+	To string
+	// Message is the received message.
+	Message *ifs.Message
+	// Err is set when the receiving node cannot deliver or forward the message, it is then dropped.
+	Err error
+}
+
+// Network is a set of simulated nodes and the links between them.
+type Network struct {
+	secret string
+	nodes  map[string]*Node
+	hooks  []func(*Route)
+	mtx    sync.RWMutex
+}
+
+// NewNetwork creates an empty network whose nodes share the security secret.
+func NewNetwork(secret string) *Network {
+	return &Network{secret: secret, nodes: make(map[string]*Node)}
+}
+
+// AddNode adds a node with a new uuid and the alias, using the network secret.
+// Services and config changes should be made before the node is connected.
+func (this *Network) AddNode(alias string) *Node {
+	return this.AddNodeWithSecurity(alias, sec.NewShallowSecurityProviderWithSecret(this.secret))
+}
+
+// AddNodeWithSecurity adds a node with a new uuid, the alias and its own security provider,
+// e.g. a provider with another secret to test rejected connections.
+func (this *Network) AddNodeWithSecurity(alias string, security *sec.ShallowSecurityProvider) *Node {
+	config := &l8sysconfig.L8SysConfig{
+		LocalUuid:   ifs.NewUuid(),
+		LocalAlias:  alias,
+		MaxDataSize: DefaultMaxDataSize,
+		Services:    &l8services.L8Services{ServiceToAreas: make(map[string]*l8services.L8ServiceAreas)},
+	}
+	node := newNode(this, config, security)
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.nodes[config.LocalUuid] = node
+	return node
+}
+
+// Node returns the node of the uuid, or nil.
+func (this *Network) Node(uuid string) *Node {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	return this.nodes[uuid]
+}
+
+// OnRoute adds a hook called with every message received by a node of the network.
+// Hooks are called from the receiving goroutines and must not block.
+func (this *Network) OnRoute(hook func(*Route)) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.hooks = append(this.hooks, hook)
+}
+
+// Connect connects the nodes over a pipe. Both sides validate the connection concurrently,
+// as a dialed and an accepted connection would, and the links are added once both succeed.
+// Providers implementing ifs.ISecurityProviderHandshake validate it with nets.Handshake.
+func (this *Network) Connect(dialer, acceptor *Node) error {
+	dialConn, acceptConn := Pipe()
+	dialLink := newLink(dialer, dialConn)
+	acceptLink := newLink(acceptor, acceptConn)
+
+	acceptErr := make(chan error, 1)
+	go func() {
+		acceptErr <- validateConnection(acceptor.security, acceptConn, acceptLink.config, false)
+	}()
+	err := validateConnection(dialer.security, dialConn, dialLink.config, true)
+	if aerr := <-acceptErr; err == nil {
+		err = aerr
+	}
+	if err != nil {
+		dialConn.Close()
+		acceptConn.Close()
+		return err
+	}
+	dialer.addLink(dialLink)
+	acceptor.addLink(acceptLink)
+	return nil
+}
+
+// Star connects every node to the hub, the hub switches the messages between them
+// like a VNet does.
+func (this *Network) Star(hub *Node, nodes ...*Node) error {
+	for _, node := range nodes {
+		if err := this.Connect(node, hub); err != nil {
+			return err
+		}
+		for _, other := range nodes {
+			if other != node {
+				node.AddRoute(other.Uuid(), hub.Uuid())
+			}
+		}
+	}
+	return nil
+}
+
+// Disconnect closes the link between the nodes, if any.
+func (this *Network) Disconnect(a, b *Node) error {
+	link := a.Link(b.Uuid())
+	if link == nil {
+		return errors.New("nodes " + a.Alias() + " and " + b.Alias() + " are not connected")
+	}
+	return link.Close()
+}
+
+// Close closes all the links of the network.
+func (this *Network) Close() {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	for _, node := range this.nodes {
+		node.close()
+	}
+}
+
+func (this *Network) route(route *Route) {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	for _, hook := range this.hooks {
+		hook(route)
+	}
+}
+
+// validateConnection validates the connection with the handshake of the provider,
+// the single round trip one when the provider supports it.
+func validateConnection(security ifs.ISecurityProvider, conn net.Conn, config *l8sysconfig.L8SysConfig, dialer bool) error {
+	if handshake, ok := security.(ifs.ISecurityProviderHandshake); ok {
+		return handshake.ValidateConnectionRole(conn, config, dialer)
+	}
+	return security.ValidateConnection(conn, config)
+}
@@ -0,0 +1,305 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simnet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/types/l8services"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNoRoute is returned when a node has no link to the destination of a message.
+var ErrNoRoute = errors.New("no route to destination")
+
+// InboxSize is the number of delivered messages a node without a handler buffers.
+const InboxSize = 1024
+
+// Node is a simulated node. Messages to its uuid, or without a destination, are delivered
+// to its handler, other messages are forwarded towards their destination.
+type Node struct {
+	network   *Network
+	config    *l8sysconfig.L8SysConfig
+	security  *sec.ShallowSecurityProvider
+	resources *resources
+	links     map[string]*Link
+	routes    map[string]string
+	handler   func(*ifs.Message)
+	inbox     chan *ifs.Message
+	mtx       sync.RWMutex
+}
+
+// resources provides the node security provider to Message.Marshal and Message.Unmarshal.
+type resources struct {
+	ifs.IResources
+	security ifs.ISecurityProvider
+}
+
+func (this *resources) Security() ifs.ISecurityProvider { return this.security }
+func (this *resources) Registry() ifs.IRegistry         { return nil }
+
+func newNode(network *Network, config *l8sysconfig.L8SysConfig, security *sec.ShallowSecurityProvider) *Node {
+	return &Node{
+		network:   network,
+		config:    config,
+		security:  security,
+		resources: &resources{security: security},
+		links:     make(map[string]*Link),
+		routes:    make(map[string]string),
+		inbox:     make(chan *ifs.Message, InboxSize),
+	}
+}
+
+// Uuid returns the node uuid.
+func (this *Node) Uuid() string {
+	return this.config.LocalUuid
+}
+
+// Alias returns the node alias.
+func (this *Node) Alias() string {
+	return this.config.LocalAlias
+}
+
+// Config returns the local config of the node, each link handshake starts from a copy of it.
+func (this *Node) Config() *l8sysconfig.L8SysConfig {
+	return this.config
+}
+
+// Resources returns the resources used to marshal and unmarshal the node messages.
+// Only Security is provided.
+func (this *Node) Resources() ifs.IResources {
+	return this.resources
+}
+
+// AddService registers the service area, announced to the nodes connected afterwards.
+func (this *Node) AddService(serviceName string, serviceArea int32) {
+	areas, ok := this.config.Services.ServiceToAreas[serviceName]
+	if !ok {
+		areas = &l8services.L8ServiceAreas{Areas: make(map[int32]bool)}
+		this.config.Services.ServiceToAreas[serviceName] = areas
+	}
+	areas.Areas[serviceArea] = true
+}
+
+// AddRoute forwards the messages to the destination through the node of the via uuid.
+func (this *Node) AddRoute(destination, via string) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.routes[destination] = via
+}
+
+// Link returns the link to the node of the uuid, or nil if they are not connected.
+func (this *Node) Link(uuid string) *Link {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	return this.links[uuid]
+}
+
+// Handle sets the handler called with the messages delivered to the node, from the
+// receiving goroutine. Without a handler the messages are kept for Receive.
+func (this *Node) Handle(handler func(*ifs.Message)) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.handler = handler
+}
+
+// Receive returns the next message delivered to the node, waiting up to the timeout.
+func (this *Node) Receive(timeout time.Duration) (*ifs.Message, error) {
+	select {
+	case msg := <-this.inbox:
+		return msg, nil
+	case <-time.After(timeout):
+		return nil, errors.New("no message received by " + this.Alias() + " after " + timeout.String())
+	}
+}
+
+// Send sends the message towards its destination, over the link to the destination
+// or the link of its route.
+func (this *Node) Send(msg *ifs.Message) error {
+	link, err := this.nextLink(msg.Destination())
+	if err != nil {
+		return err
+	}
+	return link.Send(msg)
+}
+
+func (this *Node) nextLink(destination string) (*Link, error) {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	if link, ok := this.links[destination]; ok {
+		return link, nil
+	}
+	if link, ok := this.links[this.routes[destination]]; ok {
+		return link, nil
+	}
+	return nil, fmt.Errorf("%w %s from %s", ErrNoRoute, destination, this.Alias())
+}
+
+// receive reports the message to the network hooks, then delivers or forwards it.
+// A message that Message.Hop does not allow to be forwarded is dropped, and answered
+// with a fail reply giving the reason unless it is a reply, as IVNic.Forward does.
+func (this *Node) receive(link *Link, msg *ifs.Message) {
+	route := &Route{From: link.RemoteUuid(), To: this.Uuid(), Message: msg}
+	destination := msg.Destination()
+	if destination == "" || destination == this.Uuid() {
+		this.network.route(route)
+		this.deliver(msg)
+		return
+	}
+	next, err := this.nextLink(destination)
+	if err != nil {
+		route.Err = err
+		this.network.route(route)
+		return
+	}
+	route.Err = msg.Hop(this.Uuid())
+	this.network.route(route)
+	if route.Err == nil {
+		next.Send(msg)
+	} else if !msg.Reply() {
+		link.Send(msg.CloneFail(route.Err.Error(), this.Uuid()))
+	}
+}
+
+func (this *Node) deliver(msg *ifs.Message) {
+	this.mtx.RLock()
+	handler := this.handler
+	this.mtx.RUnlock()
+	if handler != nil {
+		handler(msg)
+		return
+	}
+	this.inbox <- msg
+}
+
+func (this *Node) addLink(link *Link) {
+	this.mtx.Lock()
+	this.links[link.RemoteUuid()] = link
+	this.mtx.Unlock()
+	go link.read()
+}
+
+func (this *Node) removeLink(link *Link) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.links[link.RemoteUuid()] == link {
+		delete(this.links, link.RemoteUuid())
+	}
+}
+
+func (this *Node) close() {
+	this.mtx.RLock()
+	links := make([]*Link, 0, len(this.links))
+	for _, link := range this.links {
+		links = append(links, link)
+	}
+	this.mtx.RUnlock()
+	for _, link := range links {
+		link.Close()
+	}
+}
+
+// Link is the connection of a node to a remote node. Its config holds the result of
+// the handshake: the remote uuid, alias, services and the negotiated wire version.
+type Link struct {
+	node    *Node
+	conn    net.Conn
+	config  *l8sysconfig.L8SysConfig
+	limiter *nets.RateLimiter
+	mtx     sync.Mutex
+}
+
+func newLink(node *Node, conn net.Conn) *Link {
+	return &Link{node: node, conn: conn, config: proto.Clone(node.config).(*l8sysconfig.L8SysConfig),
+		limiter: nets.NewRateLimiter(node.config)}
+}
+
+// Config returns the config negotiated by the link handshake.
+func (this *Link) Config() *l8sysconfig.L8SysConfig {
+	return this.config
+}
+
+// RateLimiter returns the limiter of the messages received over the link,
+// from the node config rate limits.
+func (this *Link) RateLimiter() *nets.RateLimiter {
+	return this.limiter
+}
+
+// RemoteUuid returns the uuid of the remote node.
+func (this *Link) RemoteUuid() string {
+	return this.config.RemoteUuid
+}
+
+// Send marshals the message with the wire version negotiated by the link and writes it.
+func (this *Link) Send(msg *ifs.Message) error {
+	msg.SetVersion(byte(this.config.WireVersion))
+	data, err := msg.Marshal(nil, this.node.resources)
+	if err != nil {
+		return err
+	}
+	return this.Write(data)
+}
+
+// Write writes a frame to the remote node, e.g. a keepalive control frame.
+func (this *Link) Write(data []byte) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return nets.Write(data, this.conn, this.config)
+}
+
+// Close closes the link on both nodes.
+func (this *Link) Close() error {
+	return this.conn.Close()
+}
+
+// read receives the messages of the link until it is closed. Control frames are skipped
+// and frames that cannot be decoded are reported to the hooks and dropped. Messages over
+// the rate limits are reported to the hooks and requests are answered with a fail reply.
+func (this *Link) read() {
+	defer this.node.removeLink(this)
+	for {
+		data, err := nets.Read(this.conn, this.config)
+		if err != nil {
+			this.conn.Close()
+			return
+		}
+		if nets.IsControlFrame(data) {
+			continue
+		}
+		limitErr := this.limiter.AllowFrame(data)
+		msg, err := nets.UnmarshalMessage(data, this.config, this.node.resources)
+		if err != nil {
+			this.node.network.route(&Route{From: this.RemoteUuid(), To: this.node.Uuid(), Err: err})
+			continue
+		}
+		if limitErr != nil {
+			this.node.network.route(&Route{From: this.RemoteUuid(), To: this.node.Uuid(), Message: msg, Err: limitErr})
+			if reply := nets.RateLimitReply(msg, limitErr, this.node.Uuid()); reply != nil {
+				this.Send(reply)
+			}
+			continue
+		}
+		this.node.receive(this, msg)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simnet
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// Pipe returns the two ends of an in-memory connection built on net.Pipe. Unlike net.Pipe,
+// writes are buffered and return without waiting for the remote side to read, as the
+// legacy handshake, see nets.LegacyHandshake, writes on both sides before reading.
+func Pipe() (net.Conn, net.Conn) {
+	a, b := net.Pipe()
+	return newBufferedConn(a), newBufferedConn(b)
+}
+
+// bufferedConn queues the written data and writes it to the pipe in the background.
+type bufferedConn struct {
+	net.Conn
+	pending [][]byte
+	closed  bool
+	err     error
+	cond    *sync.Cond
+	mtx     sync.Mutex
+}
+
+func newBufferedConn(conn net.Conn) *bufferedConn {
+	this := &bufferedConn{Conn: conn}
+	this.cond = sync.NewCond(&this.mtx)
+	go this.flush()
+	return this
+}
+
+// Write queues a copy of the data, it fails once the remote side is closed.
+func (this *bufferedConn) Write(data []byte) (int, error) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.closed {
+		return 0, net.ErrClosed
+	}
+	if this.err != nil {
+		return 0, this.err
+	}
+	this.pending = append(this.pending, append([]byte(nil), data...))
+	this.cond.Signal()
+	return len(data), nil
+}
+
+// Close closes the pipe, data not yet read by the remote side is discarded.
+func (this *bufferedConn) Close() error {
+	this.mtx.Lock()
+	this.closed = true
+	this.pending = nil
+	this.cond.Signal()
+	this.mtx.Unlock()
+	return this.Conn.Close()
+}
+
+// SetDeadline sets the read deadline, writes never block so they have no deadline.
+func (this *bufferedConn) SetDeadline(t time.Time) error {
+	return this.Conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline is a no-op, writes never block.
+func (this *bufferedConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func (this *bufferedConn) flush() {
+	for {
+		this.mtx.Lock()
+		for len(this.pending) == 0 && !this.closed {
+			this.cond.Wait()
+		}
+		if this.closed {
+			this.mtx.Unlock()
+			return
+		}
+		data := this.pending[0]
+		this.pending = this.pending[1:]
+		this.mtx.Unlock()
+		if _, err := this.Conn.Write(data); err != nil {
+			this.mtx.Lock()
+			this.err = err
+			this.pending = nil
+			this.mtx.Unlock()
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/sec"
+)
+
+func TestDedupeWindowReplay(t *testing.T) {
+	window := ifs.NewDedupeWindow(time.Minute, 100)
+	request := newVersionTestMessage()
+
+	if result, _ := window.Begin(request); result != ifs.DedupeNew {
+		t.Fatalf("Expected DedupeNew, got %d", result)
+	}
+	if result, _ := window.Begin(request.Clone()); result != ifs.DedupeInFlight {
+		t.Errorf("Expected DedupeInFlight while handling, got %d", result)
+	}
+
+	reply := request.CloneReply("local", "remote")
+	reply.SetData([]byte("reply-data"))
+	window.Complete(request, reply)
+
+	result, replay := window.Begin(request.Clone())
+	if result != ifs.DedupeReplay || replay == nil {
+		t.Fatalf("Expected DedupeReplay, got %d", result)
+	}
+	if string(replay.Data()) != "reply-data" || replay.Sequence() != request.Sequence() {
+		t.Error("Replayed reply mismatch")
+	}
+
+	other := newVersionTestMessage()
+	other.SetSequence(78)
+	if result, _ = window.Begin(other); result != ifs.DedupeNew {
+		t.Error("A different sequence is a new request")
+	}
+	notRequest := newVersionTestMessage()
+	notRequest.SetRequestReply(false, false)
+	window.Begin(notRequest)
+	if result, _ = window.Begin(notRequest); result != ifs.DedupeNew {
+		t.Error("Messages that are not requests should not be deduped")
+	}
+}
+
+func TestDedupeWindowIdempotencyKey(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	window := ifs.NewDedupeWindow(time.Minute, 100)
+
+	request := newVersionTestMessage()
+	request.SetVersion(ifs.WireVersion4)
+	request.SetIdempotencyKey("order-42")
+	data, err := request.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	received := &ifs.Message{}
+	if _, err = received.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if received.IdempotencyKey() != "order-42" {
+		t.Fatalf("Idempotency key mismatch: %s", received.IdempotencyKey())
+	}
+
+	window.Begin(received)
+	window.Complete(received, received.CloneReply("local", "remote"))
+
+	// The retry uses a new sequence but the same key
+	retry := received.Clone()
+	retry.SetSequence(99)
+	result, replay := window.Begin(retry)
+	if result != ifs.DedupeReplay {
+		t.Fatalf("Expected DedupeReplay for the same idempotency key, got %d", result)
+	}
+	if replay.Sequence() != 99 {
+		t.Error("Replayed reply should answer the retry sequence")
+	}
+}
+
+func TestDedupeWindowIdempotencyKeyPerClient(t *testing.T) {
+	window := ifs.NewDedupeWindow(time.Minute, 100)
+	request := newVersionTestMessage()
+	request.SetSource("client-A")
+	request.SetIdempotencyKey("order-42")
+	window.Begin(request)
+	reply := request.CloneReply("local", "client-A")
+	reply.SetData([]byte("reply-for-A"))
+	window.Complete(request, reply)
+
+	other := request.Clone()
+	other.SetSource("client-B")
+	if result, replay := window.Begin(other); result != ifs.DedupeNew || replay != nil {
+		t.Errorf("Another client with the same key should not get the cached reply, got %d", result)
+	}
+	otherUser := request.Clone()
+	otherUser.SetAAAId("another-user")
+	if result, _ := window.Begin(otherUser); result != ifs.DedupeNew {
+		t.Errorf("Another aaa id with the same key should be a new request, got %d", result)
+	}
+	if result, replay := window.Begin(request.Clone()); result != ifs.DedupeReplay || string(replay.Data()) != "reply-for-A" {
+		t.Errorf("The same client should still get its reply, got %d", result)
+	}
+}
+
+func TestDedupeWindowLimits(t *testing.T) {
+	window := ifs.NewDedupeWindow(20*time.Millisecond, 2)
+	for i := uint32(1); i <= 3; i++ {
+		msg := newVersionTestMessage()
+		msg.SetSequence(i)
+		window.Begin(msg)
+	}
+	if window.Size() != 2 {
+		t.Errorf("Expected the oldest request evicted, got %d entries", window.Size())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	msg := newVersionTestMessage()
+	msg.SetSequence(3)
+	if result, _ := window.Begin(msg); result != ifs.DedupeNew || window.Size() != 1 {
+		t.Error("Expired requests should be forgotten")
+	}
+
+	window.Forget(msg)
+	if result, _ := window.Begin(msg); result != ifs.DedupeNew {
+		t.Error("A forgotten request should be handled again")
+	}
+}
@@ -0,0 +1,193 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	aeslib "github.com/saichler/l8types/go/aes"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+)
+
+func TestEncryptDecryptAEAD(t *testing.T) {
+	key := aeslib.GenerateAES256Key()
+	data := []byte("authenticated payload")
+	header := []byte("routing header")
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		enc, err := aeslib.EncryptAEAD(data, header, key)
+		if err != nil {
+			t.Fatalf("EncryptAEAD failed: %v", err)
+		}
+		dec, err := aeslib.DecryptAEAD(enc, header, key)
+		if err != nil {
+			t.Fatalf("DecryptAEAD failed: %v", err)
+		}
+		if !bytes.Equal(dec, data) {
+			t.Errorf("Data mismatch: expected %s, got %s", data, dec)
+		}
+	})
+
+	t.Run("TamperedCiphertext", func(t *testing.T) {
+		enc, _ := aeslib.EncryptAEAD(data, header, key)
+		raw, _ := base64.StdEncoding.DecodeString(enc)
+		raw[len(raw)-1] ^= 0x01
+		if _, err := aeslib.DecryptAEAD(base64.StdEncoding.EncodeToString(raw), header, key); err == nil {
+			t.Error("Expected error for tampered ciphertext")
+		}
+	})
+
+	t.Run("TamperedAdditionalData", func(t *testing.T) {
+		enc, _ := aeslib.EncryptAEAD(data, header, key)
+		if _, err := aeslib.DecryptAEAD(enc, []byte("another header"), key); err == nil {
+			t.Error("Expected error for modified additional data")
+		}
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		enc, _ := aeslib.EncryptAEAD(data, header, key)
+		if _, err := aeslib.DecryptAEAD(enc, header, aeslib.GenerateAES256Key()); err == nil {
+			t.Error("Expected error for wrong key")
+		}
+	})
+
+	t.Run("ShortData", func(t *testing.T) {
+		short := base64.StdEncoding.EncodeToString([]byte("short"))
+		if _, err := aeslib.DecryptAEAD(short, header, key); err == nil {
+			t.Error("Expected error for short data")
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		if _, err := aeslib.EncryptAEAD(data, header, "bad"); err == nil {
+			t.Error("Expected error for invalid key")
+		}
+	})
+}
+
+func TestMessageAEADHeaderBinding(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion2)
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		newMsg := &ifs.Message{}
+		if _, err := newMsg.Unmarshal(data, resources); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if newMsg.Version() != ifs.WireVersion2 || !bytes.Equal(newMsg.Data(), []byte("test-data")) {
+			t.Error("Message mismatch after AEAD round trip")
+		}
+	})
+
+	t.Run("TamperedDestination", func(t *testing.T) {
+		tampered := append([]byte(nil), data...)
+		tampered[ifs.PPriority-20] ^= 0x01
+		if _, err := (&ifs.Message{}).Unmarshal(tampered, resources); err == nil {
+			t.Error("Expected error for a modified header")
+		}
+	})
+
+	t.Run("TamperedPriority", func(t *testing.T) {
+		tampered := append([]byte(nil), data...)
+		tampered[ifs.PPriority] ^= 0x10
+		if _, err := (&ifs.Message{}).Unmarshal(tampered, resources); err == nil {
+			t.Error("Expected error for a modified priority")
+		}
+	})
+
+	t.Run("ProviderWithoutAEAD", func(t *testing.T) {
+		if _, err := msg.Marshal(nil, newMockResources()); err == nil {
+			t.Error("Expected error when the provider has no authenticated encryption")
+		}
+		if _, err := (&ifs.Message{}).Unmarshal(data, newMockResources()); err == nil {
+			t.Error("Expected error when the provider has no authenticated encryption")
+		}
+	})
+}
+
+func TestLocalCapabilitiesAEAD(t *testing.T) {
+	if nets.LocalCapabilities(sec.NewShallowSecurityProvider()).MaxVersion != uint32(ifs.WireVersionMax) {
+		t.Error("AEAD capable provider should offer the highest wire version")
+	}
+	if nets.LocalCapabilities(&MockSecurityProviderNets{}).MaxVersion != uint32(ifs.WireVersion1) {
+		t.Error("Provider without AEAD should not offer wire version 2")
+	}
+}
+
+func TestMessageAEADDowngrade(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion2)
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if _, err = (&ifs.Message{}).UnmarshalMinVersion(data, resources, ifs.WireVersion2); err != nil {
+		t.Fatalf("Unmarshal at the negotiated version failed: %v", err)
+	}
+
+	// A legacy frame on a link that negotiated authenticated encryption
+	msg.SetVersion(ifs.WireVersionLegacy)
+	legacy, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	_, err = (&ifs.Message{}).UnmarshalMinVersion(legacy, resources, ifs.WireVersion2)
+	if !errors.Is(err, ifs.ErrMessageVersion) {
+		t.Errorf("Expected ErrMessageVersion for a downgraded frame, got %v", err)
+	}
+	if _, err = (&ifs.Message{}).Unmarshal(legacy, resources); err != nil {
+		t.Errorf("Unmarshal without a minimum should accept legacy frames, got %v", err)
+	}
+}
+
+func TestReadMessageNegotiatedVersion(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	config := newHandshakeConfig("read-uuid", "read", "")
+	config.WireVersion = uint32(ifs.WireVersion2)
+	dialConn, acceptConn := tcpPair(t)
+
+	msg := newVersionTestMessage()
+	for _, version := range []byte{ifs.WireVersionLegacy, ifs.WireVersion2} {
+		msg.SetVersion(version)
+		data, err := msg.Marshal(nil, resources)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		go nets.Write(data, dialConn, config)
+		received, err := nets.ReadMessage(acceptConn, config, resources)
+		if version < ifs.WireVersion2 {
+			if !errors.Is(err, ifs.ErrMessageVersion) {
+				t.Errorf("Expected ErrMessageVersion for a downgraded frame, got %v", err)
+			}
+			continue
+		}
+		if err != nil || received.Sequence() != 77 {
+			t.Errorf("ReadMessage at the negotiated version failed: %v", err)
+		}
+	}
+}
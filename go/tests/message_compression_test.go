@@ -0,0 +1,145 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/types/l8services"
+)
+
+func TestMessageCompressionRoundTrip(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	payload := bytes.Repeat([]byte("compressible-payload-"), 500)
+
+	var uncompressedSize int
+	for _, compression := range []ifs.Compression{ifs.CompressionNone, ifs.CompressionFlate, ifs.CompressionGzip} {
+		msg := newVersionTestMessage()
+		msg.SetVersion(ifs.WireVersion4)
+		msg.SetData(payload)
+		msg.SetCompression(compression)
+
+		data, err := msg.Marshal(nil, resources)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if compression == ifs.CompressionNone {
+			uncompressedSize = len(data)
+		} else if len(data) >= uncompressedSize/4 {
+			t.Errorf("Codec %d did not compress: %d vs %d bytes", compression, len(data), uncompressedSize)
+		}
+
+		newMsg := &ifs.Message{}
+		if _, err = newMsg.Unmarshal(data, resources); err != nil {
+			t.Fatalf("Unmarshal failed for codec %d: %v", compression, err)
+		}
+		if !bytes.Equal(newMsg.Data(), payload) {
+			t.Errorf("Data mismatch for codec %d", compression)
+		}
+		if newMsg.Compression() != compression {
+			t.Errorf("Expected codec %d, got %d", compression, newMsg.Compression())
+		}
+		if msg.CloneReply("local", "remote").Compression() != compression {
+			t.Error("Reply should keep the codec of the request")
+		}
+	}
+}
+
+func TestMessageCompressionSkipped(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+
+	testCases := []struct {
+		name    string
+		version byte
+		data    []byte
+	}{
+		{"BelowThreshold", ifs.WireVersion4, bytes.Repeat([]byte("a"), ifs.CompressionMinSize-1)},
+		{"BeforeV4", ifs.WireVersion3, bytes.Repeat([]byte("a"), 4096)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := newVersionTestMessage()
+			msg.SetVersion(tc.version)
+			msg.SetData(tc.data)
+			msg.SetCompression(ifs.CompressionFlate)
+
+			data, err := msg.Marshal(nil, resources)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			newMsg := &ifs.Message{}
+			if _, err = newMsg.Unmarshal(data, resources); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if newMsg.Compression() != ifs.CompressionNone {
+				t.Error("Data should not be compressed")
+			}
+			if !bytes.Equal(newMsg.Data(), tc.data) {
+				t.Error("Data mismatch")
+			}
+		})
+	}
+}
+
+func TestDecompressLimits(t *testing.T) {
+	if _, err := ifs.Decompress([]byte("not compressed"), ifs.CompressionGzip); err == nil {
+		t.Error("Expected error for corrupt gzip data")
+	}
+	if _, err := ifs.Decompress([]byte("x"), ifs.Compression(9)); err == nil {
+		t.Error("Expected error for unknown codec")
+	}
+
+	bomb, err := ifs.Compress(make([]byte, 1<<20), ifs.CompressionFlate)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	saved := ifs.MaxDecompressedSize
+	ifs.MaxDecompressedSize = 1024
+	defer func() { ifs.MaxDecompressedSize = saved }()
+	if _, err = ifs.Decompress(bomb, ifs.CompressionFlate); err == nil {
+		t.Error("Expected error when decompressed data exceeds the maximum size")
+	}
+}
+
+func TestNegotiateCompression(t *testing.T) {
+	aead := nets.LocalCapabilities(sec.NewShallowSecurityProvider())
+	_, features := nets.NegotiateCapabilities(aead, aead)
+	if ifs.CompressionFor(features) != ifs.CompressionFlate {
+		t.Errorf("Expected flate, got %d", ifs.CompressionFor(features))
+	}
+
+	gzipOnly := &l8services.L8WireCapabilities{MaxVersion: uint32(ifs.WireVersion4), Features: ifs.FeatureCompressionGzip}
+	_, features = nets.NegotiateCapabilities(aead, gzipOnly)
+	if ifs.CompressionFor(features) != ifs.CompressionGzip {
+		t.Errorf("Expected gzip, got %d", ifs.CompressionFor(features))
+	}
+
+	// Compression needs WireVersion4 to carry the codec
+	v3 := &l8services.L8WireCapabilities{MaxVersion: uint32(ifs.WireVersion3), Features: ifs.FeatureCompressionFlate}
+	_, features = nets.NegotiateCapabilities(aead, v3)
+	if ifs.CompressionFor(features) != ifs.CompressionNone {
+		t.Error("Compression should not be negotiated below wire version 4")
+	}
+
+	_, features = nets.NegotiateCapabilities(nets.LocalCapabilities(&MockSecurityProviderNets{}), aead)
+	if features&(ifs.FeatureCompressionFlate|ifs.FeatureCompressionGzip) != 0 {
+		t.Error("Providers without AEAD should not negotiate compression")
+	}
+}
@@ -0,0 +1,100 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/sec"
+)
+
+func TestMessageDeadlineRoundTrip(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion4)
+	deadline := time.Now().Add(10 * time.Minute)
+	msg.SetDeadline(deadline)
+	if msg.Timeout() != math.MaxUint16 {
+		t.Errorf("Legacy timeout should be clamped, got %d", msg.Timeout())
+	}
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	received, ok := newMsg.Deadline()
+	if !ok || !received.Equal(time.Unix(0, deadline.UnixNano())) {
+		t.Errorf("Deadline mismatch: %v vs %v", received, deadline)
+	}
+	if reply, _ := newMsg.CloneReply("local", "remote").Deadline(); !reply.Equal(received) {
+		t.Error("Reply should keep the deadline")
+	}
+}
+
+func TestMessageDeadlineContext(t *testing.T) {
+	msg := newVersionTestMessage()
+	msg.SetTimeoutDuration(2 * time.Hour)
+	ctx, cancel := msg.Context(context.Background())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) < time.Hour {
+		t.Errorf("Context deadline should not be truncated: %v", deadline)
+	}
+
+	legacy := newVersionTestMessage()
+	legacy.SetTimeout(50)
+	ctx, cancel = legacy.Context(context.Background())
+	defer cancel()
+	if deadline, ok = ctx.Deadline(); !ok || time.Until(deadline) > 50*time.Millisecond {
+		t.Error("Messages without a deadline should use the millisecond timeout")
+	}
+
+	none := newVersionTestMessage()
+	ctx, cancel = none.Context(context.Background())
+	defer cancel()
+	if _, ok = ctx.Deadline(); ok {
+		t.Error("Messages without a deadline or timeout should have no context deadline")
+	}
+}
+
+func TestMessageDeadlineHop(t *testing.T) {
+	msg := newVersionTestMessage()
+	msg.SetDeadline(time.Now().Add(-time.Second))
+	if !msg.Expired() {
+		t.Error("Message should be expired")
+	}
+	err := msg.Hop("vnet-1")
+	if !errors.Is(err, ifs.ErrDeadlineExceeded) {
+		t.Fatalf("Expected ErrDeadlineExceeded, got %v", err)
+	}
+	if len(msg.Visited()) != 0 {
+		t.Error("Expired message should not be recorded as forwarded")
+	}
+
+	msg.SetTimeoutDuration(time.Minute)
+	if msg.Expired() || msg.Hop("vnet-1") != nil {
+		t.Error("Message within its deadline should be forwarded")
+	}
+}
@@ -0,0 +1,140 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/sec"
+)
+
+func newExtendedHeaderMessage() *ifs.Message {
+	msg := newVersionTestMessage()
+	msg.SetServiceName("a-very-descriptive-service-name")
+	msg.SetFailMessage(strings.Repeat("F", 1000))
+	msg.SetTr_State(ifs.Failed)
+	msg.SetTr_Id("tr-id")
+	msg.SetTr_ErrMsg(strings.Repeat("E", 70000))
+	return msg
+}
+
+func TestExtendedHeaderRoundTrip(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newExtendedHeaderMessage()
+	msg.SetVersion(ifs.WireVersion3)
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if err = ifs.ValidateHeader(data); err != nil {
+		t.Fatalf("ValidateHeader failed: %v", err)
+	}
+
+	_, _, _, serviceName, serviceArea, priority, _ := ifs.HeaderOf(data)
+	if serviceName != msg.ServiceName() {
+		t.Errorf("HeaderOf service name mismatch: expected %s, got %s", msg.ServiceName(), serviceName)
+	}
+	if serviceArea != 1 || priority != ifs.P1 {
+		t.Errorf("HeaderOf routing mismatch: %d %d", serviceArea, priority)
+	}
+
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if newMsg.ServiceName() != msg.ServiceName() {
+		t.Errorf("Service name mismatch: expected %s, got %s", msg.ServiceName(), newMsg.ServiceName())
+	}
+	if newMsg.FailMessage() != msg.FailMessage() {
+		t.Errorf("Fail message length mismatch: expected %d, got %d", len(msg.FailMessage()), len(newMsg.FailMessage()))
+	}
+	if newMsg.Tr_ErrMsg() != msg.Tr_ErrMsg() {
+		t.Errorf("Transaction error length mismatch: expected %d, got %d", len(msg.Tr_ErrMsg()), len(newMsg.Tr_ErrMsg()))
+	}
+	if newMsg.Tr_State() != ifs.Failed || string(newMsg.Data()) != "test-data" {
+		t.Error("Body mismatch after extended header round trip")
+	}
+}
+
+func TestExtendedHeaderLegacyTruncation(t *testing.T) {
+	resources := newMockResources()
+	msg := newExtendedHeaderMessage()
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal of a truncated legacy frame failed: %v", err)
+	}
+	if newMsg.FailMessage() != strings.Repeat("F", 255) {
+		t.Errorf("Expected fail message truncated to 255, got %d", len(newMsg.FailMessage()))
+	}
+	if newMsg.Tr_ErrMsg() != strings.Repeat("E", 255) {
+		t.Errorf("Expected transaction error truncated to 255, got %d", len(newMsg.Tr_ErrMsg()))
+	}
+	if newMsg.ServiceName() != "a-very-des" {
+		t.Errorf("Expected service name truncated to 10, got %s", newMsg.ServiceName())
+	}
+	if newMsg.Tr_State() != ifs.Failed || string(newMsg.Data()) != "test-data" {
+		t.Error("Body should stay intact when text fields are truncated")
+	}
+}
+
+func TestExtendedHeaderMalformed(t *testing.T) {
+	resources := newFuzzResources()
+	msg := newExtendedHeaderMessage()
+	msg.SetVersion(ifs.WireVersion3)
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	t.Run("TruncatedServiceName", func(t *testing.T) {
+		short := data[:ifs.PVersion+5]
+		if err := ifs.ValidateHeader(short); !errors.Is(err, ifs.ErrMessageTruncated) {
+			t.Errorf("Expected ErrMessageTruncated, got %v", err)
+		}
+		if _, err := (&ifs.Message{}).Unmarshal(short, resources); !errors.Is(err, ifs.ErrMessageTruncated) {
+			t.Errorf("Expected ErrMessageTruncated, got %v", err)
+		}
+		_, _, _, serviceName, _, _, _ := ifs.HeaderOf(short)
+		if serviceName != "a-very-des" {
+			t.Errorf("HeaderOf should fall back to the fixed field, got %s", serviceName)
+		}
+	})
+
+	t.Run("ServiceNameSizeOverflow", func(t *testing.T) {
+		bad := append([]byte(nil), data...)
+		copy(bad[ifs.PVersion+1:], []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01})
+		if err := ifs.ValidateHeader(bad); err == nil {
+			t.Error("Expected error for an overflowing service name size")
+		}
+	})
+
+	t.Run("TruncatedTextField", func(t *testing.T) {
+		short := data[:len(data)-70000]
+		if _, err := (&ifs.Message{}).Unmarshal(short, resources); !errors.Is(err, ifs.ErrMessageTruncated) {
+			t.Errorf("Expected ErrMessageTruncated, got %v", err)
+		}
+	})
+}
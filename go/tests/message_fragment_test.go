@@ -0,0 +1,170 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+func newFragmentTestMessage(size int) *ifs.Message {
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion4)
+	data := make([]byte, size)
+	rand.Read(data)
+	msg.SetData(data)
+	msg.SetMetadata("tenant", "acme")
+	return msg
+}
+
+func TestMessageFragmentRoundTrip(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	config := &l8sysconfig.L8SysConfig{MaxDataSize: 16 * 1024}
+	msg := newFragmentTestMessage(100 * 1024)
+
+	fragments, err := msg.Fragment(nets.FragmentSize(config))
+	if err != nil {
+		t.Fatalf("Fragment failed: %v", err)
+	}
+	if len(fragments) < 2 {
+		t.Fatalf("Expected several fragments, got %d", len(fragments))
+	}
+
+	reassembler := ifs.NewReassembler(time.Minute, 1024*1024)
+	var result *ifs.Message
+	// Deliver in reverse order, the order fragments arrive in must not matter
+	for i := len(fragments) - 1; i >= 0; i-- {
+		data, err := fragments[i].Marshal(nil, resources)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if uint64(len(data)) > config.MaxDataSize {
+			t.Fatalf("Fragment of %d bytes exceeds the max data size", len(data))
+		}
+		received := &ifs.Message{}
+		if _, err = received.Unmarshal(data, resources); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if !received.IsFragment() || received.FragmentIndex() != uint32(i) {
+			t.Fatalf("Expected fragment %d", i)
+		}
+		result, err = reassembler.Add(received)
+		if err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if i > 0 && result != nil {
+			t.Fatal("Message completed before all fragments were received")
+		}
+	}
+	if result == nil {
+		t.Fatal("Message was not reassembled")
+	}
+	if !bytes.Equal(result.Data(), msg.Data()) {
+		t.Error("Reassembled data mismatch")
+	}
+	if result.IsFragment() || result.Sequence() != msg.Sequence() || result.MetadataValue("tenant") != "acme" {
+		t.Error("Reassembled message fields mismatch")
+	}
+	if reassembler.Pending() != 0 || reassembler.MemoryUsed() != 0 {
+		t.Error("Reassembler should release completed messages")
+	}
+}
+
+func TestMessageFragmentSmallAndLegacy(t *testing.T) {
+	msg := newFragmentTestMessage(100)
+	fragments, err := msg.Fragment(1024)
+	if err != nil || len(fragments) != 1 || fragments[0] != msg || msg.IsFragment() {
+		t.Error("A message that fits should not be fragmented")
+	}
+	result, err := ifs.NewReassembler(time.Minute, 1024).Add(msg)
+	if err != nil || result != msg {
+		t.Error("Reassembler should pass through messages that are not fragments")
+	}
+
+	msg = newFragmentTestMessage(4096)
+	msg.SetVersion(ifs.WireVersion3)
+	if _, err = msg.Fragment(1024); err == nil {
+		t.Error("Expected error for fragmenting below wire version 4")
+	}
+}
+
+func TestReassemblerLimits(t *testing.T) {
+	msg := newFragmentTestMessage(4096)
+	fragments, err := msg.Fragment(1024)
+	if err != nil {
+		t.Fatalf("Fragment failed: %v", err)
+	}
+
+	t.Run("MemoryLimit", func(t *testing.T) {
+		reassembler := ifs.NewReassembler(time.Minute, 4096+1024)
+		if _, err := reassembler.Add(fragments[0]); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		other := newFragmentTestMessage(4096)
+		other.SetSequence(78)
+		otherFragments, _ := other.Fragment(1024)
+		if _, err := reassembler.Add(otherFragments[0]); !errors.Is(err, ifs.ErrFragmentLimit) {
+			t.Errorf("Expected ErrFragmentLimit, got %v", err)
+		}
+		if reassembler.MemoryUsed() != 4096 {
+			t.Errorf("Expected 4096 bytes reserved, got %d", reassembler.MemoryUsed())
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		reassembler := ifs.NewReassembler(10*time.Millisecond, 1024*1024)
+		reassembler.Add(fragments[0])
+		time.Sleep(20 * time.Millisecond)
+		if reassembler.Expire() != 1 || reassembler.Pending() != 0 || reassembler.MemoryUsed() != 0 {
+			t.Error("Expired message should be dropped")
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		reassembler := ifs.NewReassembler(time.Minute, 1024*1024)
+		var result *ifs.Message
+		for _, fragment := range append([]*ifs.Message{fragments[1]}, fragments...) {
+			var err error
+			if result, err = reassembler.Add(fragment); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+		}
+		if result == nil || !bytes.Equal(result.Data(), msg.Data()) {
+			t.Error("Duplicate fragments should be ignored")
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		reassembler := ifs.NewReassembler(time.Minute, 1024*1024)
+		reassembler.Add(fragments[0])
+		oversized := fragments[1].Clone()
+		oversized.SetData(make([]byte, 4096))
+		if _, err := reassembler.Add(oversized); !errors.Is(err, ifs.ErrFragmentInvalid) {
+			t.Errorf("Expected ErrFragmentInvalid, got %v", err)
+		}
+		if reassembler.Pending() != 0 {
+			t.Error("Mismatching fragment should drop the message")
+		}
+	})
+}
@@ -0,0 +1,198 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// The seed corpus for the fuzz targets lives in testdata/fuzz and runs with every go test.
+// Run "go test -fuzz=FuzzMessageUnmarshal ./tests" to explore further.
+
+// fuzzSecurityProvider adds pass-through authenticated encryption to the mock provider,
+// so fuzzing reaches the body decoding of every wire version.
+type fuzzSecurityProvider struct {
+	MockSecurityProvider
+}
+
+func (this *fuzzSecurityProvider) EncryptAEAD(data, additionalData []byte) (string, error) {
+	return string(data), nil
+}
+
+func (this *fuzzSecurityProvider) DecryptAEAD(data string, additionalData []byte) ([]byte, error) {
+	return []byte(data), nil
+}
+
+func newFuzzResources() ifs.IResources {
+	return &versionResources{security: &fuzzSecurityProvider{}}
+}
+
+func marshalFuzzSeed(tb testing.TB, trState ifs.TransactionState, version ...byte) []byte {
+	msg := newVersionTestMessage()
+	msg.SetTr_State(trState)
+	msg.SetTr_Id("tr-id")
+	msg.SetTr_ErrMsg("tr-err")
+	msg.SetFailMessage("failed")
+	msg.SetMetadata("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if len(version) > 0 {
+		msg.SetVersion(version[0])
+	}
+	data, err := msg.Marshal(nil, newFuzzResources())
+	if err != nil {
+		tb.Fatalf("Marshal failed: %v", err)
+	}
+	return data
+}
+
+func marshalCompressedFuzzSeed(tb testing.TB) []byte {
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion4)
+	msg.SetData(bytes.Repeat([]byte("data"), ifs.CompressionMinSize))
+	msg.SetCompression(ifs.CompressionFlate)
+	data, err := msg.Marshal(nil, newFuzzResources())
+	if err != nil {
+		tb.Fatalf("Marshal failed: %v", err)
+	}
+	return data
+}
+
+func FuzzMessageUnmarshal(f *testing.F) {
+	f.Add(marshalFuzzSeed(f, ifs.NotATransaction))
+	f.Add(marshalFuzzSeed(f, ifs.Running))
+	f.Add(marshalFuzzSeed(f, ifs.Running, ifs.WireVersion3))
+	f.Add(marshalFuzzSeed(f, ifs.Running, ifs.WireVersion4))
+	f.Add(marshalCompressedFuzzSeed(f))
+	resources := newFuzzResources()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msg := &ifs.Message{}
+		_, err := msg.Unmarshal(data, resources)
+		if err != nil {
+			decodeErr := &ifs.MessageDecodeError{}
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("Unexpected error type %T: %v", err, err)
+			}
+			return
+		}
+		// Anything that decodes must survive a round trip
+		out, err := msg.Marshal(nil, resources)
+		if err != nil {
+			t.Fatalf("Marshal of decoded message failed: %v", err)
+		}
+		if _, err = (&ifs.Message{}).Unmarshal(out, resources); err != nil {
+			t.Fatalf("Unmarshal of re-marshaled message failed: %v", err)
+		}
+	})
+}
+
+func FuzzHeaderOf(f *testing.F) {
+	f.Add(marshalFuzzSeed(f, ifs.NotATransaction))
+	f.Add(marshalFuzzSeed(f, ifs.NotATransaction, ifs.WireVersion3))
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ifs.HeaderOf(data)
+		ifs.ToDestination(data)
+		ifs.ToServiceName(data)
+		ifs.VersionOf(data)
+		ifs.ValidateHeader(data)
+	})
+}
+
+func TestUnmarshalMalformedFrames(t *testing.T) {
+	resources := newMockResources()
+	valid := marshalFuzzSeed(t, ifs.Running)
+	headerSize := ifs.PVersion
+
+	testCases := []struct {
+		name   string
+		data   func() []byte
+		target error
+	}{
+		{"Empty", func() []byte { return nil }, ifs.ErrMessageTruncated},
+		{"ShortHeader", func() []byte { return valid[:headerSize-1] }, ifs.ErrMessageTruncated},
+		{"HeaderOnly", func() []byte { return valid[:headerSize] }, ifs.ErrMessageTruncated},
+		{"ShortBody", func() []byte { return valid[:headerSize+10] }, ifs.ErrMessageTruncated},
+		{"BadRequestReplyFlags", func() []byte {
+			data := append([]byte(nil), valid...)
+			data[headerSize+38+4+2] = 0xFF
+			return data
+		}, ifs.ErrMessageInvalid},
+		{"FailMessageSizeOverflow", func() []byte {
+			data := append([]byte(nil), valid[:headerSize+38+4+2+1+1]...)
+			data[len(data)-1] = 200
+			return data
+		}, ifs.ErrMessageTruncated},
+		{"DataSizeOverflow", func() []byte {
+			data := append([]byte(nil), valid...)
+			pDataSize := headerSize + 38 + 4 + 2 + 1 + 1 + len("failed")
+			copy(data[pDataSize:], ifs.UInt322Bytes(0xFFFFFFFF))
+			return data
+		}, ifs.ErrMessageTruncated},
+		{"TruncatedTransaction", func() []byte { return valid[:len(valid)-5] }, ifs.ErrMessageTruncated},
+		{"UnsupportedVersion", func() []byte {
+			data := append([]byte(nil), valid[:headerSize]...)
+			return append(data, 0x80|(ifs.WireVersionMax+1))
+		}, ifs.ErrMessageVersion},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&ifs.Message{}).Unmarshal(tc.data(), resources)
+			if !errors.Is(err, tc.target) {
+				t.Fatalf("Expected %v, got %v", tc.target, err)
+			}
+			decodeErr := &ifs.MessageDecodeError{}
+			if !errors.As(err, &decodeErr) || decodeErr.Field == "" {
+				t.Errorf("Expected a MessageDecodeError with a field, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHeaderHelpersShortInput(t *testing.T) {
+	source, vnet, destination, serviceName, area, priority, mode := ifs.HeaderOf([]byte{1, 2, 3})
+	if source != "" || vnet != "" || destination != "" || serviceName != "" || area != 0 || priority != 0 || mode != 0 {
+		t.Error("HeaderOf should return zero values for a short frame")
+	}
+	if ifs.ToDestination(make([]byte, 40)) != "" {
+		t.Error("ToDestination should return empty for a short frame")
+	}
+	if ifs.ToServiceName(make([]byte, 40)) != "" {
+		t.Error("ToServiceName should return empty for a short frame")
+	}
+	if ifs.ValidateHeader(make([]byte, ifs.PVersion)) != nil {
+		t.Error("ValidateHeader should accept a complete header")
+	}
+}
+
+func TestBoolOfChecked(t *testing.T) {
+	for b := byte(0); b <= 3; b++ {
+		request, reply, err := ifs.BoolOfChecked(b)
+		if err != nil {
+			t.Errorf("BoolOfChecked(%d) failed: %v", b, err)
+		}
+		expectedRequest, expectedReply := ifs.BoolOf(b)
+		if request != expectedRequest || reply != expectedReply {
+			t.Errorf("BoolOfChecked(%d) mismatch", b)
+		}
+	}
+	if _, _, err := ifs.BoolOfChecked(4); !errors.Is(err, ifs.ErrMessageInvalid) {
+		t.Errorf("Expected ErrMessageInvalid, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/sec"
+)
+
+func TestMessageHopLimit(t *testing.T) {
+	msg := newVersionTestMessage()
+	msg.SetHopLimit(2)
+	if err := msg.Hop("vnet-1"); err != nil {
+		t.Fatalf("Hop failed: %v", err)
+	}
+	if err := msg.Hop("vnet-2"); err != nil {
+		t.Fatalf("Hop failed: %v", err)
+	}
+	err := msg.Hop("vnet-3")
+	if !errors.Is(err, ifs.ErrHopLimitExceeded) {
+		t.Fatalf("Expected ErrHopLimitExceeded, got %v", err)
+	}
+
+	fail := msg.CloneFail(err.Error(), "vnet-3")
+	if !strings.Contains(fail.FailMessage(), "hop limit exceeded") || !fail.Reply() {
+		t.Errorf("Fail reply should say why the message was dropped: %s", fail.FailMessage())
+	}
+	if fail.HopLimit() != 0 || len(fail.Visited()) != 0 {
+		t.Error("Fail reply should start with a fresh hop budget")
+	}
+
+	unset := newVersionTestMessage()
+	if err = unset.Hop("vnet-1"); err != nil || unset.HopLimit() != ifs.DefaultHopLimit-1 {
+		t.Errorf("Expected default hop limit, got %d", unset.HopLimit())
+	}
+}
+
+func TestMessageRoutingLoop(t *testing.T) {
+	msg := newVersionTestMessage()
+	for _, vnet := range []string{"vnet-1", "vnet-2"} {
+		if err := msg.Hop(vnet); err != nil {
+			t.Fatalf("Hop failed: %v", err)
+		}
+	}
+	forwarded := msg.Clone()
+	if err := forwarded.Hop("vnet-1"); !errors.Is(err, ifs.ErrRoutingLoop) {
+		t.Errorf("Expected ErrRoutingLoop, got %v", err)
+	}
+	forwarded.Hop("vnet-3")
+	if len(msg.Visited()) != 2 {
+		t.Error("Clones should not share the visited path")
+	}
+}
+
+func TestMessageHopsRoundTrip(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion4)
+	msg.Hop("vnet-1")
+	msg.Hop("vnet-2")
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if newMsg.HopLimit() != ifs.DefaultHopLimit-2 {
+		t.Errorf("Hop limit mismatch: %d", newMsg.HopLimit())
+	}
+	if len(newMsg.Visited()) != 2 || newMsg.Visited()[1] != "vnet-2" {
+		t.Errorf("Visited mismatch: %v", newMsg.Visited())
+	}
+	if err = newMsg.Hop("vnet-2"); !errors.Is(err, ifs.ErrRoutingLoop) {
+		t.Error("Loop detection should survive the wire")
+	}
+}
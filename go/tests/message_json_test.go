@@ -0,0 +1,94 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMessageJSON(t *testing.T) {
+	msg := newVersionTestMessage()
+	msg.SetTr_State(ifs.Running)
+	msg.SetTr_Id("tr-id")
+	msg.SetMetadata("tenant", "acme")
+
+	data, err := msg.ToJSON(nil, "")
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	view := &ifs.MessageView{}
+	if err = json.Unmarshal(data, view); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if view.ServiceName != "test-svc" || view.Action != "POST" || view.Priority != "P1" || view.MulticastMode != "All" {
+		t.Errorf("View mismatch: %s %s %s %s", view.ServiceName, view.Action, view.Priority, view.MulticastMode)
+	}
+	if string(view.Data) != "test-data" || view.Metadata["tenant"] != "acme" {
+		t.Error("Data or metadata mismatch")
+	}
+	if view.Transaction == nil || view.Transaction.State != "Running" || view.Transaction.Id != "tr-id" {
+		t.Error("Transaction view mismatch")
+	}
+
+	str := msg.String()
+	if strings.Contains(str, "\n") || !strings.Contains(str, `"sequence":77`) || strings.Contains(str, `"data"`) {
+		t.Errorf("String should be single line JSON without data: %s", str)
+	}
+	if _, err = json.Marshal(msg); err != nil {
+		t.Errorf("json.Marshal of a message failed: %v", err)
+	}
+}
+
+func TestMessageJSONPayload(t *testing.T) {
+	query := &l8api.L8Query{Text: "select * from Items"}
+	data, err := proto.Marshal(query)
+	if err != nil {
+		t.Fatalf("proto.Marshal failed: %v", err)
+	}
+	msg := newVersionTestMessage()
+	msg.SetData(data)
+
+	view := msg.View(newMockResources(), "l8api.L8Query")
+	if view.PayloadError != "" {
+		t.Fatalf("Payload decode failed: %s", view.PayloadError)
+	}
+	if !strings.Contains(string(view.Payload), "select * from Items") || view.Data != nil {
+		t.Errorf("Payload mismatch: %s", view.Payload)
+	}
+
+	view = msg.View(newMockResources(), "l8api.NoSuchType")
+	if view.PayloadError == "" || view.Data == nil {
+		t.Error("Unknown payload types should keep the raw data with the error")
+	}
+}
+
+func TestActionString(t *testing.T) {
+	if ifs.GET.String() != "GET" || ifs.MapR_GET.String() != "MapR_GET" || ifs.StreamClose.String() != "StreamClose" {
+		t.Error("Action names mismatch")
+	}
+	if ifs.Action(200).String() != "Unknown" || ifs.Priority(9).String() != "Unknown" {
+		t.Error("Unknown values should be named Unknown")
+	}
+	if ifs.P8.String() != "P8" || ifs.M_Unicast.String() != "Unicast" {
+		t.Error("Priority or multicast mode names mismatch")
+	}
+}
@@ -0,0 +1,211 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/sec"
+)
+
+func TestMessageMetadataRoundTrip(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion4)
+	msg.SetMetadata("tenant", "acme")
+	msg.SetMetadata("client-version", "1.2.3")
+	msg.SetMetadata("empty", "")
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	// Metadata is encrypted with the body
+	if bytes.Contains(data, []byte("acme")) {
+		t.Error("Metadata should not appear in clear text")
+	}
+
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(newMsg.Metadata()) != 3 {
+		t.Fatalf("Expected 3 metadata entries, got %d", len(newMsg.Metadata()))
+	}
+	if newMsg.MetadataValue("tenant") != "acme" {
+		t.Errorf("Tenant mismatch: %s", newMsg.MetadataValue("tenant"))
+	}
+	if v, ok := newMsg.Metadata()["empty"]; !ok || v != "" {
+		t.Error("Empty metadata value should be kept")
+	}
+	if newMsg.Sequence() != 77 || !bytes.Equal(newMsg.Data(), []byte("test-data")) {
+		t.Error("Body mismatch after unmarshal with metadata")
+	}
+
+	// Same metadata always produces the same extension bytes
+	again, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if len(again) != len(data) {
+		t.Error("Marshal of the same metadata should have the same size")
+	}
+}
+
+func TestMessageMetadataNotSentBeforeV4(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion3)
+	msg.SetMetadata("tenant", "acme")
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if newMsg.Metadata() != nil {
+		t.Error("Metadata should not be carried before wire version 4")
+	}
+}
+
+func TestMessageMetadataClone(t *testing.T) {
+	msg := newVersionTestMessage()
+	msg.SetMetadata("tenant", "acme")
+
+	clones := map[string]*ifs.Message{
+		"Clone":      msg.Clone(),
+		"CloneReply": msg.CloneReply("local", "remote"),
+		"CloneFail":  msg.CloneFail("failed", "remote"),
+	}
+	for name, clone := range clones {
+		if clone.MetadataValue("tenant") != "acme" {
+			t.Errorf("%s did not copy the metadata", name)
+		}
+		clone.SetMetadata("tenant", "other")
+	}
+	if msg.MetadataValue("tenant") != "acme" {
+		t.Error("Clones should not share the metadata map")
+	}
+
+	msg.DeleteMetadata("tenant")
+	if _, ok := msg.Metadata()["tenant"]; ok {
+		t.Error("DeleteMetadata did not delete the key")
+	}
+}
+
+func TestMessageExtensionsMalformed(t *testing.T) {
+	// The identity provider leaves the body in clear text so extensions can be appended to the frame
+	resources := newFuzzResources()
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion4)
+	msg.SetMetadata("key", "value")
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	// Unknown extension tags are skipped
+	unknown := append(append([]byte{}, data...), 99, 2, 'x', 'y')
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(unknown, resources); err != nil {
+		t.Fatalf("Unknown extension should be skipped: %v", err)
+	}
+	if newMsg.MetadataValue("key") != "value" {
+		t.Error("Metadata lost when skipping an unknown extension")
+	}
+
+	// An extension larger than the remaining body is rejected
+	oversized := append(append([]byte{}, data...), 99, 10, 'x')
+	if _, err = (&ifs.Message{}).Unmarshal(oversized, resources); err == nil {
+		t.Error("Expected error for a truncated extension")
+	}
+}
+
+func TestTraceParent(t *testing.T) {
+	value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tp, err := ifs.ParseTraceParent(value)
+	if err != nil {
+		t.Fatalf("ParseTraceParent failed: %v", err)
+	}
+	if !tp.Sampled() || tp.String() != value {
+		t.Errorf("Traceparent mismatch: %s", tp.String())
+	}
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	for _, v := range invalid {
+		if _, err = ifs.ParseTraceParent(v); err == nil {
+			t.Errorf("Expected error for %q", v)
+		}
+	}
+	// Higher versions may append fields
+	if _, err = ifs.ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Errorf("Future version should parse: %v", err)
+	}
+
+	child := tp.Child()
+	if child.TraceId != tp.TraceId || child.SpanId == tp.SpanId || child.Flags != tp.Flags {
+		t.Error("Child should keep the trace id and flags with a new span id")
+	}
+	if !ifs.NewTraceParent(true).Sampled() || ifs.NewTraceParent(false).Sampled() {
+		t.Error("NewTraceParent sampled flag mismatch")
+	}
+}
+
+func TestMessageTraceContextPropagation(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion4)
+	if msg.TraceParent() != nil {
+		t.Error("New message should have no traceparent")
+	}
+
+	root := msg.ContinueTrace()
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	received := &ifs.Message{}
+	if _, err = received.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if received.TraceParent().String() != root.String() {
+		t.Error("Traceparent did not survive the round trip")
+	}
+
+	forwarded := received.Clone()
+	hop := forwarded.ContinueTrace()
+	if hop.TraceId != root.TraceId || hop.SpanId == root.SpanId {
+		t.Error("Forwarded message should continue the same trace in a new span")
+	}
+	if received.TraceParent().SpanId != root.SpanId {
+		t.Error("Continuing the trace on a clone should not change the original")
+	}
+}
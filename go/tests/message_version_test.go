@@ -0,0 +1,227 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/types/l8services"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+func newVersionTestMessage() *ifs.Message {
+	msg := &ifs.Message{}
+	msg.Init("test-destination", "test-svc", 1, ifs.P1, ifs.M_All, ifs.POST,
+		"test-source", "test-vnet", []byte("test-data"), true, false, 77,
+		ifs.NotATransaction, "", "", 0, 0, 0, 0, 0, 0, false)
+	return msg
+}
+
+func TestMessageVersionLegacyDefault(t *testing.T) {
+	resources := newMockResources()
+	msg := newVersionTestMessage()
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if ifs.VersionOf(data) != ifs.WireVersionLegacy {
+		t.Errorf("Expected legacy version, got %d", ifs.VersionOf(data))
+	}
+
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if newMsg.Version() != ifs.WireVersionLegacy {
+		t.Errorf("Expected legacy version, got %d", newMsg.Version())
+	}
+}
+
+func TestMessageVersionMarshalUnmarshal(t *testing.T) {
+	resources := newMockResources()
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion1)
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if ifs.VersionOf(data) != ifs.WireVersion1 {
+		t.Errorf("Expected version %d, got %d", ifs.WireVersion1, ifs.VersionOf(data))
+	}
+
+	// Routing offsets must not move for versioned frames
+	_, _, _, serviceName, serviceArea, priority, _ := ifs.HeaderOf(data)
+	if serviceName != "test-svc" || serviceArea != 1 || priority != ifs.P1 {
+		t.Errorf("Header mismatch: %s %d %d", serviceName, serviceArea, priority)
+	}
+
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if newMsg.Version() != ifs.WireVersion1 {
+		t.Errorf("Expected version %d, got %d", ifs.WireVersion1, newMsg.Version())
+	}
+	if newMsg.Sequence() != 77 || !bytes.Equal(newMsg.Data(), []byte("test-data")) {
+		t.Error("Body mismatch after versioned unmarshal")
+	}
+	if newMsg.Clone().Version() != ifs.WireVersion1 {
+		t.Error("Clone should keep the wire version")
+	}
+}
+
+func TestMessageVersionWithShallowSecurity(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+
+	for _, version := range []byte{ifs.WireVersionLegacy, ifs.WireVersion1} {
+		msg := newVersionTestMessage()
+		msg.SetVersion(version)
+		data, err := msg.Marshal(nil, resources)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if ifs.VersionOf(data) != version {
+			t.Errorf("Expected version %d, got %d", version, ifs.VersionOf(data))
+		}
+		newMsg := &ifs.Message{}
+		if _, err = newMsg.Unmarshal(data, resources); err != nil {
+			t.Fatalf("Unmarshal failed for version %d: %v", version, err)
+		}
+		if newMsg.Sequence() != 77 {
+			t.Errorf("Sequence mismatch for version %d", version)
+		}
+	}
+}
+
+func TestMessageVersionUnsupported(t *testing.T) {
+	resources := newMockResources()
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion1)
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	data[ifs.PVersion] = 0x80 | (ifs.WireVersionMax + 1)
+	if _, err = (&ifs.Message{}).Unmarshal(data, resources); err == nil {
+		t.Error("Expected error for unsupported wire version")
+	}
+}
+
+func TestNegotiateCapabilities(t *testing.T) {
+	local := &l8services.L8WireCapabilities{MinVersion: 0, MaxVersion: 3, Features: 0x70}
+
+	version, features := nets.NegotiateCapabilities(local, nil)
+	if version != ifs.WireVersionLegacy || features != 0 {
+		t.Error("Nil remote should negotiate legacy")
+	}
+
+	version, features = nets.NegotiateCapabilities(local, &l8services.L8WireCapabilities{MinVersion: 1, MaxVersion: 2, Features: 0x50})
+	if version != 2 || features != 0x50 {
+		t.Errorf("Expected version 2 features 0x50, got %d %x", version, features)
+	}
+
+	version, _ = nets.NegotiateCapabilities(local, &l8services.L8WireCapabilities{MinVersion: 4, MaxVersion: 5})
+	if version != ifs.WireVersionLegacy {
+		t.Errorf("Expected legacy for disjoint versions, got %d", version)
+	}
+}
+
+func TestExecuteProtocolCapabilities(t *testing.T) {
+	frame := func(data []byte) []byte {
+		return append(ifs.Long2Bytes(int64(len(data))), data...)
+	}
+	newConfig := func() *l8sysconfig.L8SysConfig {
+		return &l8sysconfig.L8SysConfig{LocalUuid: "local-uuid", MaxDataSize: 1024}
+	}
+
+	t.Run("Negotiated", func(t *testing.T) {
+		remote := &l8services.L8Services{
+			ServiceToAreas: map[string]*l8services.L8ServiceAreas{"svc": {Areas: map[int32]bool{1: true}}},
+			Capabilities:   nets.LocalCapabilities(&MockSecurityProviderNets{}),
+		}
+		conn := NewMockConn()
+		conn.SetReadData(bytes.Join([][]byte{frame([]byte("remote-uuid")), frame([]byte("false")),
+			frame([]byte("remote-alias")), frame(nets.ServicesToBytes(remote)), frame([]byte("vnet"))}, nil))
+
+		config := newConfig()
+		if err := nets.ExecuteProtocol(conn, config, &MockSecurityProviderNets{}); err != nil {
+			t.Fatalf("ExecuteProtocol failed: %v", err)
+		}
+		// The mock provider has no authenticated encryption, so version 1 is the highest common
+		if config.WireVersion != uint32(ifs.WireVersion1) {
+			t.Errorf("Expected wire version %d, got %d", ifs.WireVersion1, config.WireVersion)
+		}
+		if config.Services.GetCapabilities() != nil {
+			t.Error("Remote capabilities should not leak into the services")
+		}
+		if config.Services.ServiceToAreas["svc"] == nil {
+			t.Error("Remote services should be kept")
+		}
+	})
+
+	t.Run("LegacyPeer", func(t *testing.T) {
+		conn := NewMockConn()
+		conn.SetReadData(bytes.Join([][]byte{frame([]byte("remote-uuid")), frame([]byte("false")),
+			frame([]byte("remote-alias")), frame(nets.ServicesToBytes(&l8services.L8Services{})), frame([]byte("vnet"))}, nil))
+
+		config := newConfig()
+		config.WireVersion = 9
+		if err := nets.ExecuteProtocol(conn, config, &MockSecurityProviderNets{}); err != nil {
+			t.Fatalf("ExecuteProtocol failed: %v", err)
+		}
+		if config.WireVersion != uint32(ifs.WireVersionLegacy) {
+			t.Errorf("Expected legacy wire version, got %d", config.WireVersion)
+		}
+	})
+
+	t.Run("LocalCapabilitiesSent", func(t *testing.T) {
+		conn := NewMockConn()
+		conn.SetReadData(bytes.Join([][]byte{frame([]byte("remote-uuid")), frame([]byte("false")),
+			frame([]byte("remote-alias")), frame(nets.ServicesToBytes(&l8services.L8Services{})), frame([]byte("vnet"))}, nil))
+
+		config := newConfig()
+		config.Services = &l8services.L8Services{}
+		if err := nets.ExecuteProtocol(conn, config, &MockSecurityProviderNets{}); err != nil {
+			t.Fatalf("ExecuteProtocol failed: %v", err)
+		}
+
+		// Skip uuid, force external and alias frames written by the local side
+		written := conn.GetWrittenData()
+		for i := 0; i < 3; i++ {
+			written = written[8+ifs.Bytes2Long(written[:8]):]
+		}
+		size := ifs.Bytes2Long(written[:8])
+		sent := nets.BytesToServices(written[8 : 8+size])
+		if sent.GetCapabilities().GetMaxVersion() != uint32(ifs.WireVersion1) {
+			t.Error("Local capabilities were not sent")
+		}
+	})
+}
+
+// versionResources wraps MockResources with a real security provider.
+type versionResources struct {
+	MockResources
+	security ifs.ISecurityProvider
+}
+
+func (this *versionResources) Security() ifs.ISecurityProvider { return this.security }
@@ -0,0 +1,142 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// replayListener collects the messages replayed into it.
+type replayListener struct {
+	messages []*ifs.Message
+}
+
+func (this *replayListener) ShutdownVNic(ifs.IVNic)           {}
+func (this *replayListener) Failed([]byte, ifs.IVNic, string) {}
+func (this *replayListener) HandleData(data []byte, _ ifs.IVNic) {
+	msg := &ifs.Message{}
+	if _, err := msg.Unmarshal(data, newMockResources()); err == nil {
+		this.messages = append(this.messages, msg)
+	}
+}
+
+func captureTestFrame(t *testing.T, sequence uint32) []byte {
+	msg := newVersionTestMessage()
+	msg.SetSequence(sequence)
+	data, err := msg.Marshal(nil, newMockResources())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	return data
+}
+
+func TestCaptureReadWrite(t *testing.T) {
+	config := &l8sysconfig.L8SysConfig{MaxDataSize: 1024 * 1024}
+	file := &bytes.Buffer{}
+	capture, err := nets.NewCapture(file)
+	if err != nil {
+		t.Fatalf("NewCapture failed: %v", err)
+	}
+
+	in := captureTestFrame(t, 1)
+	mock := NewMockConn()
+	mock.SetReadData(append(ifs.Long2Bytes(int64(len(in))), in...))
+	conn := nets.CaptureConn(mock, capture)
+
+	if _, err = nets.Read(conn, config); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	out := captureTestFrame(t, 2)
+	if err = nets.Write(out, conn, config); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !bytes.Equal(mock.GetWrittenData()[8:], out) {
+		t.Error("Captured connection should write through")
+	}
+
+	reader, err := nets.NewCaptureReader(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatalf("NewCaptureReader failed: %v", err)
+	}
+	first, err := reader.Next()
+	if err != nil || first.Direction != nets.CaptureIn || !bytes.Equal(first.Data, in) {
+		t.Fatal("First record should be the frame read")
+	}
+	second, err := reader.Next()
+	if err != nil || second.Direction != nets.CaptureOut || !bytes.Equal(second.Data, out) {
+		t.Fatal("Second record should be the frame written")
+	}
+	if second.Time.Before(first.Time) {
+		t.Error("Records should be in time order")
+	}
+	if _, err = reader.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestCaptureWrapped(t *testing.T) {
+	config := &l8sysconfig.L8SysConfig{MaxDataSize: 1024 * 1024}
+	file := &bytes.Buffer{}
+	capture, _ := nets.NewCapture(file)
+	conn := nets.NewFaultConn(nets.CaptureConn(NewMockConn(), capture), nets.FaultScript{})
+	if err := nets.Write(captureTestFrame(t, 1), conn, config); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	reader, _ := nets.NewCaptureReader(bytes.NewReader(file.Bytes()))
+	if record, err := reader.Next(); err != nil || record.Direction != nets.CaptureOut {
+		t.Errorf("Frames should be captured under another decorator, got %v", err)
+	}
+}
+
+func TestCaptureReplay(t *testing.T) {
+	file := &bytes.Buffer{}
+	capture, _ := nets.NewCapture(file)
+	capture.Record(nets.CaptureIn, []byte("handshake-uuid"))
+	// A hello frame long enough to pass as a message header
+	capture.Record(nets.CaptureIn, append([]byte("\x00L8HELLO"), bytes.Repeat([]byte{1}, ifs.PVersion*2)...))
+	for i := uint32(1); i <= 3; i++ {
+		capture.Record(nets.CaptureIn, captureTestFrame(t, i))
+		capture.Record(nets.CaptureOut, captureTestFrame(t, 100+i))
+	}
+
+	listener := &replayListener{}
+	count, err := nets.Replay(bytes.NewReader(file.Bytes()), listener, nil, false)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if count != 3 || len(listener.messages) != 3 {
+		t.Fatalf("Expected 3 replayed frames, got %d", count)
+	}
+	for i, msg := range listener.messages {
+		if msg.Sequence() != uint32(i+1) {
+			t.Errorf("Replay out of order: %d at %d", msg.Sequence(), i)
+		}
+	}
+
+	if _, err = nets.Replay(bytes.NewReader([]byte("garbage")), listener, nil, false); err == nil {
+		t.Error("Expected error for a file that is not a capture")
+	}
+	truncated := file.Bytes()[:file.Len()-5]
+	if _, err = nets.Replay(bytes.NewReader(truncated), &replayListener{}, nil, false); err == nil {
+		t.Error("Expected error for a truncated capture")
+	}
+}
@@ -0,0 +1,146 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+)
+
+func TestFaultConnCorruption(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion4)
+	msg.SetTr_State(ifs.Rollback)
+	frame, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	corruptAfterReads := func(seed int64, reads int) []byte {
+		mock := NewMockConn()
+		mock.SetReadData(frame)
+		conn := nets.NewFaultConn(mock, nets.FaultScript{Seed: seed, CorruptRate: 1, Jitter: time.Microsecond})
+		for i := 0; i < reads; i++ {
+			conn.Read(make([]byte, 1))
+		}
+		if _, err := conn.Write(frame); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if conn.Stats().Corrupted != 1 {
+			t.Error("Expected one corrupted write")
+		}
+		return mock.GetWrittenData()
+	}
+	corrupt := func(seed int64) []byte {
+		return corruptAfterReads(seed, 0)
+	}
+	corrupted := corrupt(7)
+	if bytes.Equal(corrupted, frame) {
+		t.Fatal("The frame should be corrupted")
+	}
+	if !bytes.Equal(corrupted, corrupt(7)) {
+		t.Error("The same seed should corrupt the same byte")
+	}
+	if !bytes.Equal(corrupted, corruptAfterReads(7, 3)) {
+		t.Error("The reads should not change the faults of the writes")
+	}
+	// A corrupted frame must never decode as another transaction
+	for seed := int64(0); seed < 64; seed++ {
+		decoded := &ifs.Message{}
+		if _, err = decoded.Unmarshal(corrupt(seed), resources); err == nil && decoded.Tr_State() != ifs.Rollback {
+			t.Errorf("Seed %d: corrupted frame decoded with state %s", seed, decoded.Tr_State())
+		}
+	}
+}
+
+func TestFaultConnPartialWriteAndReset(t *testing.T) {
+	mock := NewMockConn()
+	conn := nets.NewFaultConn(mock, nets.FaultScript{Seed: 1, PartialWriteRate: 1})
+	n, err := conn.Write([]byte("0123456789"))
+	if !errors.Is(err, io.ErrShortWrite) || n < 1 || n >= 10 || len(mock.GetWrittenData()) != n {
+		t.Errorf("Expected a partial write, got %d, %v", n, err)
+	}
+
+	mock = NewMockConn()
+	conn = nets.NewFaultConn(mock, nets.FaultScript{ResetAfterBytes: 15})
+	if _, err = conn.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if n, err = conn.Write([]byte("0123456789")); !errors.Is(err, nets.ErrFaultReset) || n != 5 {
+		t.Errorf("Expected a reset after 15 bytes, got %d, %v", n, err)
+	}
+	if !mock.IsClosed() || conn.Stats().Resets != 1 {
+		t.Error("A reset should close the connection")
+	}
+	if _, err = conn.Read(make([]byte, 1)); !errors.Is(err, nets.ErrFaultReset) {
+		t.Errorf("Expected ErrFaultReset after a reset, got %v", err)
+	}
+}
+
+func TestFaultConnPartition(t *testing.T) {
+	dialConn, acceptConn := tcpPair(t)
+	conn := nets.NewFaultConn(dialConn, nets.FaultScript{Latency: 20 * time.Millisecond, Jitter: 5 * time.Millisecond})
+	config := newHandshakeConfig("fault-uuid", "fault", "")
+
+	conn.Partition()
+	start := time.Now()
+	if err := nets.Write([]byte("lost"), conn, config); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("Write should be delayed by the latency")
+	}
+	conn.Heal()
+	if err := nets.Write([]byte("delivered"), conn, config); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	acceptConn.SetReadDeadline(time.Now().Add(time.Second))
+	data, err := nets.Read(acceptConn, config)
+	if err != nil || string(data) != "delivered" {
+		t.Errorf("Expected the data written after the partition, got %q, %v", data, err)
+	}
+	if conn.Stats().Dropped == 0 {
+		t.Error("The partition should drop the data")
+	}
+}
+
+func TestFaultConnHandshake(t *testing.T) {
+	dialConn, acceptConn := tcpPair(t)
+	security := &MockSecurityProviderNets{}
+	dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	acceptConfig.HandshakeTimeoutSeconds = 1
+	conn := nets.NewFaultConn(dialConn, nets.FaultScript{ResetAfterBytes: 30})
+
+	dialErr, acceptErr := runHandshakes(
+		func() error { return nets.ExecuteProtocol(conn, dialConfig, security) },
+		func() error { return nets.ExecuteProtocol(acceptConn, acceptConfig, security) })
+	var handshakeErr *nets.HandshakeError
+	if !errors.Is(dialErr, nets.ErrFaultReset) || !errors.As(dialErr, &handshakeErr) || !handshakeErr.Retryable() {
+		t.Errorf("Expected a retryable reset, got %v", dialErr)
+	}
+	if !errors.As(acceptErr, &handshakeErr) || !handshakeErr.Retryable() {
+		t.Errorf("The remote side should fail with a retryable error, got %v", acceptErr)
+	}
+}
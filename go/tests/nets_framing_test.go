@@ -0,0 +1,109 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+func TestFramingContextRoundTrip(t *testing.T) {
+	config := &l8sysconfig.L8SysConfig{MaxDataSize: 1024 * 1024}
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	frames := [][]byte{[]byte("first"), bytes.Repeat([]byte("x"), 100000), {}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		for _, frame := range frames {
+			if err := nets.WriteContext(ctx, frame, client, config); err != nil {
+				t.Errorf("WriteContext failed: %v", err)
+				return
+			}
+		}
+	}()
+	for i, frame := range frames {
+		data, err := nets.ReadContext(ctx, server, config)
+		if err != nil {
+			t.Fatalf("ReadContext %d failed: %v", i, err)
+		}
+		if !bytes.Equal(data, frame) {
+			t.Errorf("Frame %d mismatch", i)
+		}
+		nets.PutBuffer(data)
+	}
+
+	// Read and Write keep working on the same connection after the context calls
+	go nets.Write([]byte("plain"), client, config)
+	if data, err := nets.Read(server, config); err != nil || string(data) != "plain" {
+		t.Errorf("Read after ReadContext failed: %v", err)
+	}
+}
+
+func TestFramingContextCancel(t *testing.T) {
+	config := &l8sysconfig.L8SysConfig{MaxDataSize: 1024}
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	if _, err := nets.ReadContext(ctx, server, config); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Cancel should interrupt the blocked read")
+	}
+
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelTimeout()
+	if err := nets.WriteContext(timeout, []byte("nobody reads"), client, config); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// The deadline set for the context is cleared
+	go nets.Write([]byte("after"), client, config)
+	if data, err := nets.Read(server, config); err != nil || string(data) != "after" {
+		t.Errorf("Read after canceled ReadContext failed: %v", err)
+	}
+}
+
+func TestFramingBufferPool(t *testing.T) {
+	buffer := nets.GetBuffer(1000)
+	if len(buffer) != 1000 || cap(buffer) != 1024 {
+		t.Errorf("Expected a 1000 byte buffer of capacity 1024, got %d/%d", len(buffer), cap(buffer))
+	}
+	nets.PutBuffer(buffer)
+	if small := nets.GetBuffer(10); len(small) != 10 {
+		t.Errorf("Expected a 10 byte buffer, got %d", len(small))
+	}
+	if large := nets.GetBuffer(1<<24 + 1); len(large) != 1<<24+1 {
+		t.Error("Buffers larger than the pooled sizes should be allocated")
+	}
+	nets.PutBuffer(make([]byte, 10, 1000))
+}
@@ -0,0 +1,166 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+	"google.golang.org/protobuf/proto"
+)
+
+// expectHandshakeError checks the cause, step and retry hint of a handshake error.
+func expectHandshakeError(t *testing.T, err, cause error, step string, retryable bool) {
+	t.Helper()
+	if !errors.Is(err, cause) {
+		t.Fatalf("Expected %v, got %v", cause, err)
+	}
+	var handshakeErr *nets.HandshakeError
+	if !errors.As(err, &handshakeErr) {
+		t.Fatalf("Expected a *HandshakeError, got %T", err)
+	}
+	if handshakeErr.Step != step {
+		t.Errorf("Expected step %s, got %s", step, handshakeErr.Step)
+	}
+	if handshakeErr.Retryable() != retryable {
+		t.Errorf("Expected retryable %v for %v", retryable, err)
+	}
+}
+
+func TestValidateConnectionSecret(t *testing.T) {
+	dialConn, acceptConn := tcpPair(t)
+	dialErr, acceptErr := runHandshakes(
+		func() error {
+			return sec.NewShallowSecurityProvider().ValidateConnection(dialConn, newHandshakeConfig("dial-uuid", "dial", ""))
+		},
+		func() error {
+			return sec.NewShallowSecurityProvider().ValidateConnection(acceptConn, newHandshakeConfig("accept-uuid", "accept", ""))
+		})
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("ValidateConnection failed: %v / %v", dialErr, acceptErr)
+	}
+
+	dialConn, acceptConn = tcpPair(t)
+	dialErr, _ = runHandshakes(
+		func() error {
+			return sec.NewShallowSecurityProviderWithSecret("one").ValidateConnection(dialConn, newHandshakeConfig("dial-uuid", "dial", ""))
+		},
+		func() error {
+			return sec.NewShallowSecurityProviderWithSecret("two").ValidateConnection(acceptConn, newHandshakeConfig("accept-uuid", "accept", ""))
+		})
+	// A different key either fails decryption or decrypts to a different secret
+	if !errors.Is(dialErr, nets.ErrHandshakeBadSecret) && !errors.Is(dialErr, nets.ErrHandshakeDecrypt) {
+		t.Fatalf("Expected a secret mismatch, got %v", dialErr)
+	}
+	var handshakeErr *nets.HandshakeError
+	if !errors.As(dialErr, &handshakeErr) || handshakeErr.Step != "secret" || handshakeErr.Retryable() {
+		t.Errorf("Secret mismatch should not be retryable: %v", dialErr)
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	dialConn, _ := tcpPair(t)
+	config := newHandshakeConfig("dial-uuid", "dial", "")
+	config.HandshakeTimeoutSeconds = 1
+
+	// The remote node never answers
+	start := time.Now()
+	err := sec.NewShallowSecurityProvider().ValidateConnection(dialConn, config)
+	expectHandshakeError(t, err, nets.ErrHandshakeTimeout, "ack", true)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("The underlying context error should be kept")
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 5*time.Second {
+		t.Errorf("Expected the configured timeout, took %s", elapsed)
+	}
+
+	dialConn, _ = tcpPair(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = nets.HandshakeContext(ctx, dialConn, newHandshakeConfig("dial-uuid", "dial", ""), &MockSecurityProviderNets{}, true)
+	expectHandshakeError(t, err, nets.ErrHandshakeTimeout, "ack", true)
+}
+
+func TestHandshakeErrors(t *testing.T) {
+	frame := func(data []byte) []byte {
+		return append(ifs.Long2Bytes(int64(len(data))), data...)
+	}
+	// Handshake frames start with this marker, see helloMagic
+	hello := func(msg proto.Message) []byte {
+		data, _ := proto.Marshal(msg)
+		return frame(append([]byte("\x00L8HELLO"), data...))
+	}
+	config := func() *l8sysconfig.L8SysConfig {
+		return newHandshakeConfig("local-uuid", "local", "")
+	}
+
+	t.Run("FrameSize", func(t *testing.T) {
+		conn := NewMockConn()
+		conn.SetReadData(ifs.Long2Bytes(1 << 40))
+		err := nets.ExecuteProtocol(conn, config(), &MockSecurityProviderNets{})
+		expectHandshakeError(t, err, nets.ErrHandshakeFrameSize, "uuid", false)
+		if !errors.Is(err, nets.ErrFrameTooLarge) || !conn.IsClosed() {
+			t.Error("Expected ErrFrameTooLarge and a closed connection")
+		}
+	})
+
+	t.Run("Decrypt", func(t *testing.T) {
+		conn := NewMockConn()
+		conn.SetReadData(frame([]byte("remote-uuid")))
+		err := nets.ExecuteProtocol(conn, config(), &MockSecurityProviderNets{decryptError: true})
+		expectHandshakeError(t, err, nets.ErrHandshakeDecrypt, "uuid", false)
+	})
+
+	t.Run("Encrypt", func(t *testing.T) {
+		err := nets.ExecuteProtocol(NewMockConn(), config(), &MockSecurityProviderNets{encryptError: true})
+		expectHandshakeError(t, err, nets.ErrHandshakeEncrypt, "uuid", false)
+	})
+
+	t.Run("Connection", func(t *testing.T) {
+		conn := NewMockConn()
+		conn.SetReadError(os.ErrClosed)
+		err := nets.ExecuteProtocol(conn, config(), &MockSecurityProviderNets{})
+		expectHandshakeError(t, err, nets.ErrHandshakeConnection, "uuid", true)
+	})
+
+	t.Run("AckVersion", func(t *testing.T) {
+		conn := NewMockConn()
+		conn.SetReadData(hello(&l8sysconfig.L8HelloAck{Hello: &l8sysconfig.L8Hello{ProtocolVersion: nets.HandshakeVersion + 1}}))
+		err := nets.Handshake(conn, config(), &MockSecurityProviderNets{}, true)
+		expectHandshakeError(t, err, nets.ErrHandshakeVersion, "ack", false)
+	})
+
+	t.Run("HelloVersion", func(t *testing.T) {
+		conn := NewMockConn()
+		conn.SetReadData(hello(&l8sysconfig.L8Hello{Uuid: "remote-uuid"}))
+		err := nets.Handshake(conn, config(), &MockSecurityProviderNets{}, false)
+		expectHandshakeError(t, err, nets.ErrHandshakeVersion, "hello", false)
+	})
+
+	t.Run("InvalidHello", func(t *testing.T) {
+		conn := NewMockConn()
+		conn.SetReadData(frame([]byte("\x00L8HELLO\xff\xff")))
+		err := nets.Handshake(conn, config(), &MockSecurityProviderNets{}, false)
+		expectHandshakeError(t, err, nets.ErrHandshakeInvalid, "hello", false)
+	})
+}
@@ -0,0 +1,236 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/types/l8services"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// tcpPair returns the two ends of a loopback TCP connection.
+func tcpPair(t *testing.T) (net.Conn, net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+	dialed, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	acceptedConn := <-accepted
+	t.Cleanup(func() {
+		dialed.Close()
+		acceptedConn.Close()
+	})
+	return dialed, acceptedConn
+}
+
+func newHandshakeConfig(uuid, alias, vnet string) *l8sysconfig.L8SysConfig {
+	return &l8sysconfig.L8SysConfig{
+		LocalUuid:   uuid,
+		LocalAlias:  alias,
+		RemoteVnet:  vnet,
+		MaxDataSize: 1024 * 1024,
+		Services: &l8services.L8Services{
+			ServiceToAreas: map[string]*l8services.L8ServiceAreas{alias + "-svc": {Areas: map[int32]bool{1: true}}},
+		},
+	}
+}
+
+// runHandshakes runs the dialer and acceptor sides concurrently and returns their errors.
+func runHandshakes(dialer, acceptor func() error) (error, error) {
+	acceptErr := make(chan error, 1)
+	go func() {
+		acceptErr <- acceptor()
+	}()
+	dialErr := dialer()
+	return dialErr, <-acceptErr
+}
+
+func TestHandshake(t *testing.T) {
+	dialConn, acceptConn := tcpPair(t)
+	security := &MockSecurityProviderNets{}
+	dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+	dialConfig.ForceExternal = true
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "vnet-a")
+
+	dialErr, acceptErr := runHandshakes(
+		func() error { return nets.Handshake(dialConn, dialConfig, security, true) },
+		func() error { return nets.Handshake(acceptConn, acceptConfig, security, false) })
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Handshake failed: %v / %v", dialErr, acceptErr)
+	}
+
+	if dialConfig.RemoteUuid != "accept-uuid" || dialConfig.RemoteAlias != "accept" || dialConfig.RemoteVnet != "vnet-a" {
+		t.Errorf("Dialer got wrong remote info: %s %s %s", dialConfig.RemoteUuid, dialConfig.RemoteAlias, dialConfig.RemoteVnet)
+	}
+	if acceptConfig.RemoteUuid != "dial-uuid" || acceptConfig.RemoteAlias != "dial" || acceptConfig.RemoteVnet != "vnet-a" {
+		t.Errorf("Acceptor got wrong remote info: %s %s %s", acceptConfig.RemoteUuid, acceptConfig.RemoteAlias, acceptConfig.RemoteVnet)
+	}
+	if !acceptConfig.ForceExternal {
+		t.Error("Force external should be taken from the dialer")
+	}
+	if dialConfig.Services.ServiceToAreas["accept-svc"] == nil || acceptConfig.Services.ServiceToAreas["dial-svc"] == nil {
+		t.Error("Services should be exchanged")
+	}
+	if dialConfig.HandshakeVersion != nets.HandshakeVersion || acceptConfig.HandshakeVersion != nets.HandshakeVersion {
+		t.Errorf("Expected handshake version %d, got %d / %d", nets.HandshakeVersion,
+			dialConfig.HandshakeVersion, acceptConfig.HandshakeVersion)
+	}
+	if dialConfig.WireVersion != acceptConfig.WireVersion || dialConfig.WireVersion == 0 {
+		t.Errorf("Both sides should negotiate the same wire version, got %d / %d",
+			dialConfig.WireVersion, acceptConfig.WireVersion)
+	}
+
+	// The connection carries frames after the handshake
+	go nets.Write([]byte("after"), dialConn, dialConfig)
+	if data, err := nets.Read(acceptConn, acceptConfig); err != nil || string(data) != "after" {
+		t.Errorf("Read after handshake failed: %v", err)
+	}
+}
+
+func TestHandshakeLegacyDialer(t *testing.T) {
+	dialConn, acceptConn := tcpPair(t)
+	security := &MockSecurityProviderNets{}
+	dialConfig := newHandshakeConfig("legacy-uuid", "legacy", "vnet-l")
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	acceptConfig.HandshakeVersion = 7
+
+	dialErr, acceptErr := runHandshakes(
+		func() error { return nets.ExecuteProtocol(dialConn, dialConfig, security) },
+		func() error { return nets.Handshake(acceptConn, acceptConfig, security, false) })
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Legacy fallback failed: %v / %v", dialErr, acceptErr)
+	}
+	if acceptConfig.RemoteUuid != "legacy-uuid" || acceptConfig.RemoteAlias != "legacy" || acceptConfig.RemoteVnet != "vnet-l" {
+		t.Errorf("Acceptor got wrong remote info: %s %s %s", acceptConfig.RemoteUuid, acceptConfig.RemoteAlias, acceptConfig.RemoteVnet)
+	}
+	if dialConfig.RemoteUuid != "accept-uuid" || dialConfig.Services.ServiceToAreas["accept-svc"] == nil {
+		t.Error("Legacy dialer should get the acceptor info")
+	}
+	if acceptConfig.HandshakeVersion != 0 {
+		t.Errorf("Expected the legacy handshake version, got %d", acceptConfig.HandshakeVersion)
+	}
+}
+
+func TestHandshakeLegacyAcceptor(t *testing.T) {
+	dialConn, acceptConn := tcpPair(t)
+	security := &MockSecurityProviderNets{}
+	dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+	acceptConfig := newHandshakeConfig("legacy-uuid", "legacy", "")
+
+	dialErr, _ := runHandshakes(
+		func() error { return nets.Handshake(dialConn, dialConfig, security, true) },
+		func() error { return nets.ExecuteProtocol(acceptConn, acceptConfig, security) })
+	if !errors.Is(dialErr, nets.ErrLegacyPeer) {
+		t.Errorf("Expected ErrLegacyPeer, got %v", dialErr)
+	}
+}
+
+func TestHandshakeSecurityProvider(t *testing.T) {
+	dialer := sec.NewShallowSecurityProvider()
+	acceptor := sec.NewShallowSecurityProvider()
+	accept := func(conn net.Conn, config *l8sysconfig.L8SysConfig) func() error {
+		return func() error {
+			if err := acceptor.CanAccept(conn); err != nil {
+				return err
+			}
+			return acceptor.ValidateConnection(conn, config)
+		}
+	}
+
+	dialConn, acceptConn := tcpPair(t)
+	dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	dialErr, acceptErr := runHandshakes(
+		func() error { return dialer.ValidateConnection(dialConn, dialConfig) },
+		accept(acceptConn, acceptConfig))
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("ValidateConnection failed: %v / %v", dialErr, acceptErr)
+	}
+	if dialConfig.HandshakeVersion != nets.HandshakeVersion || acceptConfig.HandshakeVersion != nets.HandshakeVersion ||
+		acceptConfig.RemoteUuid != "dial-uuid" || dialConfig.RemoteUuid != "accept-uuid" {
+		t.Errorf("Expected the single round trip handshake, got version %d", dialConfig.HandshakeVersion)
+	}
+
+	// Both nodes validating the connection as dialers
+	dialConn, acceptConn = tcpPair(t)
+	dialConfig = newHandshakeConfig("dial-uuid", "dial", "")
+	acceptConfig = newHandshakeConfig("accept-uuid", "accept", "")
+	dialErr, acceptErr = runHandshakes(
+		func() error { return dialer.ValidateConnection(dialConn, dialConfig) },
+		func() error { return acceptor.ValidateConnection(acceptConn, acceptConfig) })
+	if dialErr != nil || acceptErr != nil || acceptConfig.HandshakeVersion != nets.HandshakeVersion {
+		t.Fatalf("Symmetric handshake failed: %v / %v", dialErr, acceptErr)
+	}
+
+	// A legacy dialer
+	dialConn, acceptConn = tcpPair(t)
+	acceptConfig = newHandshakeConfig("accept-uuid", "accept", "")
+	dialErr, acceptErr = runHandshakes(
+		func() error {
+			return nets.LegacyHandshake(dialConn, newHandshakeConfig("dial-uuid", "dial", ""), dialer)
+		},
+		accept(acceptConn, acceptConfig))
+	if dialErr != nil || acceptErr != nil || acceptConfig.HandshakeVersion != 0 || acceptConfig.RemoteUuid != "dial-uuid" {
+		t.Fatalf("Legacy dialer fallback failed: %v / %v", dialErr, acceptErr)
+	}
+
+	// A legacy acceptor, the dialer uses the legacy handshake when it redials it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+	for attempt := 0; attempt < 2; attempt++ {
+		dialConfig = newHandshakeConfig("dial-uuid", "dial", "")
+		dialErr, _ = runHandshakes(
+			func() error {
+				conn, err := net.Dial("tcp", listener.Addr().String())
+				if err != nil {
+					return err
+				}
+				defer conn.Close()
+				return dialer.ValidateConnection(conn, dialConfig)
+			},
+			func() error {
+				conn, err := listener.Accept()
+				if err != nil {
+					return err
+				}
+				defer conn.Close()
+				return nets.LegacyHandshake(conn, newHandshakeConfig("legacy-uuid", "legacy", ""), acceptor)
+			})
+		if attempt == 0 && !errors.Is(dialErr, nets.ErrLegacyPeer) {
+			t.Fatalf("Expected ErrLegacyPeer, got %v", dialErr)
+		}
+	}
+	if dialErr != nil || dialConfig.HandshakeVersion != 0 || dialConfig.RemoteUuid != "legacy-uuid" {
+		t.Errorf("The redial should use the legacy handshake: %v", dialErr)
+	}
+}
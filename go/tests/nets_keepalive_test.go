@@ -0,0 +1,188 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/types/l8health"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// keepaliveRecorder records the frames sent and the states reported by a keepalive.
+type keepaliveRecorder struct {
+	frames  [][]byte
+	states  []l8health.L8HealthState
+	sendErr error
+	mtx     sync.Mutex
+}
+
+func (this *keepaliveRecorder) send(data []byte) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.frames = append(this.frames, data)
+	return this.sendErr
+}
+
+func (this *keepaliveRecorder) onState(state l8health.L8HealthState) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.states = append(this.states, state)
+}
+
+func TestKeepalivePingPong(t *testing.T) {
+	config := &l8sysconfig.L8SysConfig{KeepAliveIntervalSeconds: 1, WireFeatures: ifs.FeatureKeepalive}
+	local := &keepaliveRecorder{}
+	remote := &keepaliveRecorder{}
+	localKeepalive := nets.NewKeepalive(config, local.send, local.onState)
+	remoteKeepalive := nets.NewKeepalive(config, remote.send, remote.onState)
+
+	localKeepalive.Tick()
+	if len(local.frames) != 1 || !nets.IsControlFrame(local.frames[0]) {
+		t.Fatal("Tick should send a ping control frame")
+	}
+	if !remoteKeepalive.HandleFrame(local.frames[0]) {
+		t.Fatal("A ping should be handled as a control frame")
+	}
+	if len(remote.frames) != 1 || !nets.IsControlFrame(remote.frames[0]) {
+		t.Fatal("A ping should be answered with a pong")
+	}
+	time.Sleep(time.Millisecond)
+	if !localKeepalive.HandleFrame(remote.frames[0]) {
+		t.Fatal("A pong should be handled as a control frame")
+	}
+	if rtt := localKeepalive.RTT(); rtt < time.Millisecond || rtt > time.Second {
+		t.Errorf("Unexpected RTT %s", rtt)
+	}
+	if len(local.frames) != 1 {
+		t.Error("A pong should not be answered")
+	}
+
+	// Messages are not control frames
+	msg, err := newVersionTestMessage().Marshal(nil, newMockResources())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if localKeepalive.HandleFrame(msg) || nets.IsControlFrame(msg) {
+		t.Error("A message should not be a control frame")
+	}
+	if err = ifs.ValidateHeader(local.frames[0]); err == nil {
+		t.Error("A control frame should not pass as a message")
+	}
+}
+
+func TestKeepaliveDeadPeer(t *testing.T) {
+	config := &l8sysconfig.L8SysConfig{KeepAliveMissedBeats: 2, WireFeatures: ifs.FeatureKeepalive}
+	recorder := &keepaliveRecorder{}
+	keepalive := nets.NewKeepalive(config, recorder.send, recorder.onState)
+
+	keepalive.Tick()
+	if keepalive.State() != l8health.L8HealthState_Up || keepalive.Missed() != 1 {
+		t.Fatalf("Expected Up after one missed beat, got %s", keepalive.State())
+	}
+	// Any frame from the remote side is a beat
+	keepalive.HandleFrame([]byte("application data"))
+	keepalive.Tick()
+	keepalive.Tick()
+	if keepalive.State() != l8health.L8HealthState_Up {
+		t.Fatal("A received frame should reset the missed beats")
+	}
+	keepalive.Tick()
+	if keepalive.State() != l8health.L8HealthState_Unreachable {
+		t.Fatalf("Expected Unreachable after 2 missed beats, got %s", keepalive.State())
+	}
+	keepalive.HandleFrame(recorder.frames[0])
+	if keepalive.State() != l8health.L8HealthState_Up {
+		t.Fatal("A received frame should bring the remote side Up")
+	}
+
+	recorder.sendErr = errors.New("connection reset")
+	keepalive.Tick()
+	if keepalive.State() != l8health.L8HealthState_Down {
+		t.Fatal("A failed send should mark the remote side Down")
+	}
+	expected := []l8health.L8HealthState{l8health.L8HealthState_Unreachable, l8health.L8HealthState_Up,
+		l8health.L8HealthState_Down}
+	if len(recorder.states) != len(expected) {
+		t.Fatalf("Expected states %v, got %v", expected, recorder.states)
+	}
+	for i, state := range expected {
+		if recorder.states[i] != state {
+			t.Errorf("State %d: expected %s, got %s", i, state, recorder.states[i])
+		}
+	}
+}
+
+func TestKeepaliveStartStop(t *testing.T) {
+	recorder := &keepaliveRecorder{}
+	keepalive := nets.NewKeepalive(&l8sysconfig.L8SysConfig{KeepAliveIntervalSeconds: 1, WireFeatures: ifs.FeatureKeepalive}, recorder.send, nil)
+	keepalive.Start()
+	keepalive.Start()
+	time.Sleep(1100 * time.Millisecond)
+	keepalive.Stop()
+	keepalive.Stop()
+	recorder.mtx.Lock()
+	defer recorder.mtx.Unlock()
+	if len(recorder.frames) != 1 {
+		t.Errorf("Expected a single ping, got %d", len(recorder.frames))
+	}
+}
+
+func TestKeepaliveNotNegotiated(t *testing.T) {
+	recorder := &keepaliveRecorder{}
+	keepalive := nets.NewKeepalive(&l8sysconfig.L8SysConfig{KeepAliveMissedBeats: 1}, recorder.send, recorder.onState)
+	if keepalive.Enabled() {
+		t.Fatal("The keepalive should be disabled for a peer without the feature")
+	}
+	keepalive.Tick()
+	keepalive.Tick()
+	if len(recorder.frames) != 0 || keepalive.State() != l8health.L8HealthState_Up {
+		t.Errorf("A peer without the feature should get no pings, got %d", len(recorder.frames))
+	}
+
+	// The feature is negotiated by both handshakes, and only offered by the nodes running Keepalive
+	security := &MockSecurityProviderNets{}
+	negotiate := func(dialKeepalive, acceptKeepalive bool) (*l8sysconfig.L8SysConfig, *l8sysconfig.L8SysConfig) {
+		dialConn, acceptConn := tcpPair(t)
+		dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+		dialConfig.KeepAliveFrames = dialKeepalive
+		acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+		acceptConfig.KeepAliveFrames = acceptKeepalive
+		runHandshakes(
+			func() error { return nets.ExecuteProtocol(dialConn, dialConfig, security) },
+			func() error { return nets.Handshake(acceptConn, acceptConfig, security, false) })
+		return dialConfig, acceptConfig
+	}
+	dialConfig, acceptConfig := negotiate(true, true)
+	if !nets.NewKeepalive(dialConfig, recorder.send, nil).Enabled() ||
+		!nets.NewKeepalive(acceptConfig, recorder.send, nil).Enabled() {
+		t.Error("The keepalive should be enabled between nodes that negotiated it")
+	}
+	dialConfig, acceptConfig = negotiate(true, false)
+	if nets.NewKeepalive(dialConfig, recorder.send, nil).Enabled() ||
+		nets.NewKeepalive(acceptConfig, recorder.send, nil).Enabled() {
+		t.Error("A node not running Keepalive should not get pings")
+	}
+	_, features := nets.NegotiateCapabilities(nets.LocalCapabilities(security), nil)
+	if features&ifs.FeatureKeepalive != 0 {
+		t.Error("A legacy peer should not get keepalive pings")
+	}
+}
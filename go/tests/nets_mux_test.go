@@ -0,0 +1,225 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+)
+
+// muxPair creates a mux on each side of a loopback connection.
+func muxPair(t *testing.T) (*nets.Mux, *nets.Mux) {
+	dialConn, acceptConn := tcpPair(t)
+	config := newHandshakeConfig("mux-uuid", "mux", "")
+	dialer := nets.NewMux(dialConn, config, true)
+	acceptor := nets.NewMux(acceptConn, config, false)
+	t.Cleanup(func() {
+		dialer.Close()
+		acceptor.Close()
+	})
+	return dialer, acceptor
+}
+
+func TestMuxStream(t *testing.T) {
+	dialer, acceptor := muxPair(t)
+	payload := bytes.Repeat([]byte("0123456789abcdef"), nets.MuxWindowSize/4)
+
+	stream, err := dialer.Open(ifs.P8)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	writeErr := make(chan error, 1)
+	go func() {
+		_, err := stream.Write(payload)
+		stream.Close()
+		writeErr <- err
+	}()
+
+	accepted, err := acceptor.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	if accepted.Id() != stream.Id() || accepted.Priority() != ifs.P8 {
+		t.Errorf("Unexpected stream %d at %d", accepted.Id(), accepted.Priority())
+	}
+	received, err := io.ReadAll(accepted)
+	if err != nil || !bytes.Equal(received, payload) {
+		t.Fatalf("Expected the payload larger than the window, got %d bytes, %v", len(received), err)
+	}
+	if err = <-writeErr; err != nil {
+		t.Errorf("Write failed: %v", err)
+	}
+	if _, err = stream.Write([]byte("closed")); !errors.Is(err, ifs.ErrStreamClosed) {
+		t.Errorf("Expected ErrStreamClosed, got %v", err)
+	}
+
+	// Streams opened by the acceptor use even ids
+	reverse, err := acceptor.Open(ifs.P4)
+	if err != nil || reverse.Id()%2 != 0 || stream.Id()%2 != 1 {
+		t.Errorf("Unexpected stream ids %d / %d, %v", stream.Id(), reverse.Id(), err)
+	}
+}
+
+func TestMuxHeadOfLineBlocking(t *testing.T) {
+	dialer, acceptor := muxPair(t)
+	config := newHandshakeConfig("mux-uuid", "mux", "")
+
+	// The bulk transfer is never read, it stalls once it used up its window
+	bulk, _ := dialer.Open(ifs.P8)
+	go bulk.Write(make([]byte, 4*nets.MuxWindowSize))
+	reply, _ := dialer.Open(ifs.P1)
+	if err := nets.Write([]byte("small reply"), reply, config); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	for {
+		stream, err := acceptor.Accept()
+		if err != nil {
+			t.Fatalf("Accept failed: %v", err)
+		}
+		if stream.Priority() != ifs.P1 {
+			continue
+		}
+		stream.SetReadDeadline(time.Now().Add(time.Second))
+		data, err := nets.Read(stream, config)
+		if err != nil || string(data) != "small reply" {
+			t.Fatalf("The reply should not wait for the bulk transfer, got %q, %v", data, err)
+		}
+		return
+	}
+}
+
+func TestMuxClose(t *testing.T) {
+	dialer, acceptor := muxPair(t)
+	stream, _ := dialer.Open(ifs.P4)
+	accepted, err := acceptor.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+
+	accepted.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err = accepted.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	accepted.SetReadDeadline(time.Time{})
+
+	dialer.Close()
+	if _, err = accepted.Read(make([]byte, 1)); err == nil {
+		t.Error("Read should fail once the remote mux is closed")
+	}
+	if _, err = acceptor.Accept(); err == nil {
+		t.Error("Accept should fail once the connection is closed")
+	}
+	if _, err = stream.Write([]byte("data")); !errors.Is(err, nets.ErrMuxClosed) {
+		t.Errorf("Expected ErrMuxClosed, got %v", err)
+	}
+	if _, err = dialer.Open(ifs.P1); !errors.Is(err, nets.ErrMuxClosed) {
+		t.Errorf("Expected ErrMuxClosed, got %v", err)
+	}
+}
+
+func TestMuxOpenParity(t *testing.T) {
+	dialConn, acceptConn := tcpPair(t)
+	config := newHandshakeConfig("mux-uuid", "mux", "")
+	dialer := nets.NewMux(dialConn, config, true)
+	defer dialer.Close()
+
+	// The remote side opens stream 1, which is in the range of the dialer
+	frame := append([]byte("\x00L8MUX"), nets.MuxOpen, 0, 0, 0, 1, byte(ifs.P4))
+	if err := nets.Write(frame, acceptConn, config); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := dialer.Accept(); !errors.Is(err, nets.ErrMuxProtocol) {
+		t.Errorf("Expected ErrMuxProtocol for a stream id of the local range, got %v", err)
+	}
+	if _, err := dialer.Open(ifs.P4); err == nil {
+		t.Error("The mux should be closed after a protocol error")
+	}
+}
+
+func TestMuxOpenPriority(t *testing.T) {
+	dialConn, acceptConn := tcpPair(t)
+	config := newHandshakeConfig("mux-uuid", "mux", "")
+	dialer := nets.NewMux(dialConn, config, true)
+	defer dialer.Close()
+
+	if _, err := dialer.Open(ifs.Priority(15)); err == nil {
+		t.Error("Open should reject an invalid priority")
+	}
+	frame := append([]byte("\x00L8MUX"), nets.MuxOpen, 0, 0, 0, 2, byte(ifs.P1)+1)
+	if err := nets.Write(frame, acceptConn, config); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := dialer.Accept(); !errors.Is(err, nets.ErrMuxProtocol) {
+		t.Errorf("Expected ErrMuxProtocol for an invalid priority, got %v", err)
+	}
+}
+
+func TestMuxMaxStreams(t *testing.T) {
+	dialer, acceptor := muxPair(t)
+	streams := make([]*nets.MuxStream, 0, nets.MuxMaxStreams)
+	for i := 0; i < nets.MuxMaxStreams; i++ {
+		stream, err := dialer.Open(ifs.P4)
+		if err != nil {
+			t.Fatalf("Open %d failed: %v", i, err)
+		}
+		streams = append(streams, stream)
+	}
+	if _, err := dialer.Open(ifs.P4); !errors.Is(err, nets.ErrMuxStreams) {
+		t.Fatalf("Expected ErrMuxStreams, got %v", err)
+	}
+
+	// A stream closed on both sides frees its slot
+	accepted, err := acceptor.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	accepted.Close()
+	streams[0].Close()
+	deadline := time.Now().Add(time.Second)
+	for _, err = dialer.Open(ifs.P4); err != nil && time.Now().Before(deadline); _, err = dialer.Open(ifs.P4) {
+		time.Sleep(time.Millisecond)
+	}
+	if err != nil {
+		t.Errorf("Expected the closed stream to free its slot, got %v", err)
+	}
+
+	// A remote side opening more streams than the limit fails the mux
+	dialConn, acceptConn := tcpPair(t)
+	config := newHandshakeConfig("mux-uuid", "mux", "")
+	mux := nets.NewMux(dialConn, config, true)
+	defer mux.Close()
+	for i := 1; i <= nets.MuxMaxStreams+1; i++ {
+		frame := append([]byte("\x00L8MUX"), nets.MuxOpen, 0, 0, byte(i>>7), byte(i<<1), byte(ifs.P4))
+		if err = nets.Write(frame, acceptConn, config); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	accepts := 0
+	for _, err = mux.Accept(); err == nil; _, err = mux.Accept() {
+		accepts++
+	}
+	if !errors.Is(err, nets.ErrMuxProtocol) || accepts > nets.MuxMaxStreams {
+		t.Errorf("Expected ErrMuxProtocol after %d streams, got %v after %d", nets.MuxMaxStreams, err, accepts)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+func TestPriorityQueueWeightedDequeue(t *testing.T) {
+	queue := nets.NewPriorityQueue(0, nets.OverflowReject)
+	queue.SetWeight(ifs.P1, 3)
+	for i := 0; i < 10; i++ {
+		queue.Add([]byte{byte(ifs.P8)}, ifs.P8)
+		queue.Add([]byte{byte(ifs.P1)}, ifs.P1)
+	}
+
+	// Each round serves 3 P1 frames then a single P8 frame
+	expected := []ifs.Priority{ifs.P1, ifs.P1, ifs.P1, ifs.P8, ifs.P1, ifs.P1, ifs.P1, ifs.P8}
+	for i, priority := range expected {
+		data, ok := queue.Poll()
+		if !ok || ifs.Priority(data[0]) != priority {
+			t.Fatalf("Frame %d: expected %s, got %v", i, priority, data)
+		}
+	}
+
+	for ok := true; ok; _, ok = queue.Poll() {
+	}
+	stats := queue.Stats()
+	if stats[ifs.P1].Enqueued != 10 || stats[ifs.P1].Dequeued != 10 || stats[ifs.P1].HighWater != 10 {
+		t.Errorf("Unexpected P1 stats %+v", stats[ifs.P1])
+	}
+	if stats[ifs.P8].Depth != 0 || queue.Len() != 0 {
+		t.Error("Queue should be drained")
+	}
+}
+
+func TestPriorityQueueFrames(t *testing.T) {
+	queue := nets.NewTxQueue(&l8sysconfig.L8SysConfig{TxQueueSize: 10}, nets.OverflowReject)
+	bulk := newVersionTestMessage()
+	bulk.SetPriority(ifs.P8)
+	heartbeat := newVersionTestMessage()
+	heartbeat.SetPriority(ifs.P1)
+	for _, msg := range []*ifs.Message{bulk, bulk, heartbeat} {
+		data, err := msg.Marshal(nil, newMockResources())
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if err = queue.AddFrame(data); err != nil {
+			t.Fatalf("AddFrame failed: %v", err)
+		}
+	}
+	data, _ := queue.Poll()
+	_, _, _, _, _, priority, _ := ifs.HeaderOf(data)
+	if priority != ifs.P1 {
+		t.Errorf("Heartbeat should be dequeued ahead of bulk data, got %s", priority)
+	}
+	if err := queue.AddFrame([]byte{1, 2}); err == nil {
+		t.Error("Expected error for a frame without a header")
+	}
+}
+
+func TestPriorityQueueInvalidPriority(t *testing.T) {
+	queue := nets.NewPriorityQueue(0, nets.OverflowReject)
+	queue.Add([]byte("invalid"), ifs.Priority(15))
+	queue.Add([]byte("heartbeat"), ifs.P1)
+	if data, _ := queue.Poll(); string(data) != "heartbeat" {
+		t.Errorf("An invalid priority should not jump ahead of P1, got %s", data)
+	}
+	if queue.Stats()[ifs.P8].Enqueued != 1 {
+		t.Error("An invalid priority should be queued at P8")
+	}
+}
+
+func TestPriorityQueueOverflow(t *testing.T) {
+	reject := nets.NewPriorityQueue(2, nets.OverflowReject)
+	reject.Add([]byte("a"), ifs.P5)
+	reject.Add([]byte("b"), ifs.P5)
+	if err := reject.Add([]byte("c"), ifs.P1); err != nets.ErrQueueFull {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+	if reject.Stats()[ifs.P1].Dropped != 1 {
+		t.Error("Rejected frame should be counted as dropped")
+	}
+
+	drop := nets.NewPriorityQueue(2, nets.OverflowDropLowest)
+	drop.Add([]byte("low-1"), ifs.P8)
+	drop.Add([]byte("low-2"), ifs.P8)
+	if err := drop.Add([]byte("high"), ifs.P1); err != nil {
+		t.Fatalf("Expected the lowest frame to be dropped, got %v", err)
+	}
+	if err := drop.Add([]byte("low-3"), ifs.P8); err != nets.ErrQueueFull {
+		t.Errorf("A frame should not drop frames of its own level, got %v", err)
+	}
+	first, _ := drop.Poll()
+	second, _ := drop.Poll()
+	if string(first) != "high" || string(second) != "low-2" {
+		t.Errorf("Expected high and low-2, got %s and %s", first, second)
+	}
+	if drop.Stats()[ifs.P8].Dropped != 2 {
+		t.Errorf("Expected 2 dropped P8 frames, got %d", drop.Stats()[ifs.P8].Dropped)
+	}
+
+	block := nets.NewPriorityQueue(1, nets.OverflowBlock)
+	block.Add([]byte("first"), ifs.P4)
+	added := make(chan error, 1)
+	go func() {
+		added <- block.Add([]byte("second"), ifs.P4)
+	}()
+	select {
+	case <-added:
+		t.Fatal("Add should block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if data, ok := block.Next(); !ok || string(data) != "first" {
+		t.Fatal("Expected the first frame")
+	}
+	if err := <-added; err != nil {
+		t.Errorf("Blocked Add failed: %v", err)
+	}
+}
+
+func TestPriorityQueueClose(t *testing.T) {
+	queue := nets.NewRxQueue(&l8sysconfig.L8SysConfig{RxQueueSize: 10}, nets.OverflowBlock)
+	done := make(chan bool, 1)
+	go func() {
+		_, ok := queue.Next()
+		done <- ok
+	}()
+	time.Sleep(10 * time.Millisecond)
+	queue.Close()
+	if ok := <-done; ok {
+		t.Error("Next should return false when the queue is closed")
+	}
+	if err := queue.Add([]byte("late"), ifs.P1); err != nets.ErrQueueClosed {
+		t.Errorf("Expected ErrQueueClosed, got %v", err)
+	}
+}
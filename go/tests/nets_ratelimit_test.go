@@ -0,0 +1,206 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/simnet"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+func TestRateLimiterConnection(t *testing.T) {
+	limiter := nets.NewRateLimiter(&l8sysconfig.L8SysConfig{
+		ConnectionRateLimit: &l8sysconfig.L8RateLimit{MessagesPerSecond: 10, MessageBurst: 2},
+	})
+	if limiter.Allow("svc", 10) != nil || limiter.Allow("svc", 10) != nil {
+		t.Fatal("The burst should be allowed")
+	}
+	err := limiter.Allow("svc", 10)
+	var limitErr *nets.RateLimitError
+	if !errors.Is(err, nets.ErrRateLimited) || !errors.As(err, &limitErr) {
+		t.Fatalf("Expected ErrRateLimited after the burst, got %v", err)
+	}
+	if limitErr.Service != "" || limitErr.RetryAfter <= 0 || limitErr.RetryAfter > 100*time.Millisecond {
+		t.Errorf("Unexpected connection limit error %v", limitErr)
+	}
+	time.Sleep(limitErr.RetryAfter)
+	if err = limiter.Allow("svc", 10); err != nil {
+		t.Errorf("The message should be allowed after the retry time, got %v", err)
+	}
+	if stats := limiter.Stats(); stats.Allowed != 3 || stats.Dropped != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	unlimited := nets.NewRateLimiter(&l8sysconfig.L8SysConfig{})
+	for i := 0; i < 1000; i++ {
+		if err = unlimited.Allow("svc", 1024*1024); err != nil {
+			t.Fatalf("No limit is configured, got %v", err)
+		}
+	}
+}
+
+func TestRateLimiterService(t *testing.T) {
+	limiter := nets.NewRateLimiter(&l8sysconfig.L8SysConfig{
+		ConnectionRateLimit: &l8sysconfig.L8RateLimit{MessagesPerSecond: 3},
+		ServiceRateLimits: map[string]*l8sysconfig.L8RateLimit{
+			"test-svc": {BytesPerSecond: 100, ByteBurst: 100},
+		},
+	})
+	frame, err := newVersionTestMessage().Marshal(nil, newMockResources())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	// A message larger than the burst passes on a full bucket
+	if err = limiter.AllowFrame(frame); err != nil {
+		t.Fatalf("The first message should be allowed, got %v", err)
+	}
+	var limitErr *nets.RateLimitError
+	if err = limiter.AllowFrame(frame); !errors.As(err, &limitErr) || limitErr.Service != "test-svc" {
+		t.Fatalf("Expected the service limit, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "test-svc") {
+		t.Errorf("The error should give the service, got %s", err)
+	}
+	// The rejected message did not take from the connection limit
+	if limiter.Allow("other-svc", 10) != nil || limiter.Allow("other-svc", 10) != nil {
+		t.Error("Other services should only be limited by the connection limit")
+	}
+	if err = limiter.Allow("other-svc", 10); !errors.As(err, &limitErr) || limitErr.Service != "" {
+		t.Errorf("Expected the connection limit, got %v", err)
+	}
+}
+
+func TestRateLimiterLargeMessages(t *testing.T) {
+	limiter := nets.NewRateLimiter(&l8sysconfig.L8SysConfig{
+		ConnectionRateLimit: &l8sysconfig.L8RateLimit{BytesPerSecond: 1000, ByteBurst: 1000},
+	})
+	if err := limiter.Allow("svc", 5000); err != nil {
+		t.Fatalf("A message larger than the burst should pass on a full bucket, got %v", err)
+	}
+	// The message charged its full size, the next waits until the debt is paid
+	var limitErr *nets.RateLimitError
+	if err := limiter.Allow("svc", 5000); !errors.As(err, &limitErr) {
+		t.Fatalf("Expected the byte limit, got %v", err)
+	}
+	if limitErr.RetryAfter < 4*time.Second || limitErr.RetryAfter > 5*time.Second {
+		t.Errorf("RetryAfter should include the debt of the large message, got %s", limitErr.RetryAfter)
+	}
+	if err := limiter.Allow("svc", 1); err == nil {
+		t.Error("A small message should also wait for the debt to be paid")
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := nets.NewRateLimiter(&l8sysconfig.L8SysConfig{
+		ConnectionRateLimit: &l8sysconfig.L8RateLimit{MessagesPerSecond: 50, MessageBurst: 1},
+	})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background(), "svc", 1); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Wait should pace the messages at the rate, took %s", elapsed)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx, "svc", 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+}
+
+func TestRateLimiterWriteAndReply(t *testing.T) {
+	config := &l8sysconfig.L8SysConfig{
+		MaxDataSize:       1024 * 1024,
+		ServiceRateLimits: map[string]*l8sysconfig.L8RateLimit{"test-svc": {MessagesPerSecond: 50, MessageBurst: 1}},
+	}
+	msg := newVersionTestMessage()
+	frame, err := msg.Marshal(nil, newMockResources())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	dialConn, acceptConn := tcpPair(t)
+	sender := nets.NewRateLimiter(config)
+	receiver := nets.NewRateLimiter(config)
+	go func() {
+		for i := 0; i < 3; i++ {
+			sender.Write(context.Background(), frame, dialConn, config)
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		data, err := nets.Read(acceptConn, config)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if err = receiver.AllowFrame(data); err != nil {
+			t.Errorf("A paced sender should not be limited, got %v", err)
+		}
+	}
+
+	limitErr := receiver.AllowFrame(frame)
+	reply := nets.RateLimitReply(msg, limitErr, "local-uuid")
+	if reply == nil || !reply.Reply() || reply.Destination() != msg.Source() ||
+		!strings.Contains(reply.FailMessage(), "test-svc") {
+		t.Fatalf("Expected a fail reply giving the limit, got %+v", reply)
+	}
+	if nets.RateLimitReply(reply, limitErr, "local-uuid") != nil {
+		t.Error("A reply should not be answered")
+	}
+}
+
+func TestRateLimiterFailReply(t *testing.T) {
+	network := simnet.NewNetwork("sim-secret")
+	defer network.Close()
+	recorder := &routeRecorder{}
+	network.OnRoute(recorder.onRoute)
+	hub := network.AddNode("hub")
+	hub.Config().ConnectionRateLimit = &l8sysconfig.L8RateLimit{MessagesPerSecond: 1}
+	a := network.AddNode("a")
+	if err := network.Connect(a, hub); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	for _, data := range []string{"first", "flood"} {
+		if err := a.Send(newSimMessage(a, hub.Uuid(), data)); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	msg, err := hub.Receive(time.Second)
+	if err != nil || string(msg.Data()) != "first" {
+		t.Fatalf("The first message should be delivered, got %v", err)
+	}
+	reply, err := a.Receive(time.Second)
+	if err != nil {
+		t.Fatalf("The dropped message should be answered: %v", err)
+	}
+	if !reply.Reply() || string(reply.Data()) != "flood" || !strings.Contains(reply.FailMessage(), nets.ErrRateLimited.Error()) {
+		t.Errorf("Expected a fail reply giving the reason, got %q", reply.FailMessage())
+	}
+	routes := recorder.get()
+	if len(routes) < 2 || !errors.Is(routes[1].Err, nets.ErrRateLimited) {
+		t.Errorf("The dropped message should be reported to the hooks, got %+v", routes)
+	}
+	if stats := hub.Link(a.Uuid()).RateLimiter().Stats(); stats.Dropped != 1 {
+		t.Errorf("Expected one dropped message, got %+v", stats)
+	}
+}
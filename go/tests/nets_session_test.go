@@ -0,0 +1,239 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// connectSessions runs DialSession and AcceptSession over a new loopback connection.
+func connectSessions(t *testing.T, dialSession *nets.Session, cache *nets.SessionCache,
+	dialConfig, acceptConfig *l8sysconfig.L8SysConfig) (*nets.Session, *nets.Session, error, error) {
+	dialConn, acceptConn := tcpPair(t)
+	security := &MockSecurityProviderNets{}
+	var dialer, acceptor *nets.Session
+	dialErr, acceptErr := runHandshakes(
+		func() (err error) {
+			dialer, err = nets.DialSession(dialConn, dialConfig, security, dialSession)
+			return err
+		},
+		func() (err error) {
+			acceptor, err = nets.AcceptSession(acceptConn, acceptConfig, security, cache)
+			return err
+		})
+	return dialer, acceptor, dialErr, acceptErr
+}
+
+// readFrames reads the frames of a session and returns them as strings.
+func readFrames(t *testing.T, session *nets.Session, count int) []string {
+	frames := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		data, err := session.Read()
+		if err != nil {
+			t.Fatalf("Read failed after %d frames: %v", i, err)
+		}
+		frames = append(frames, string(data))
+	}
+	return frames
+}
+
+func TestSessionResume(t *testing.T) {
+	dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	cache := nets.NewSessionCache(acceptConfig)
+	dialer, acceptor, dialErr, acceptErr := connectSessions(t, nil, cache, dialConfig, acceptConfig)
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Session handshake failed: %v / %v", dialErr, acceptErr)
+	}
+	if dialer.Ticket() == "" || dialer.Ticket() != acceptor.Ticket() || cache.Len() != 1 {
+		t.Fatal("The acceptor should issue a session ticket")
+	}
+
+	for _, frame := range []string{"one", "two", "three"} {
+		if err := dialer.Write([]byte(frame)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if frames := readFrames(t, acceptor, 1); frames[0] != "one" {
+		t.Fatalf("Unexpected frame %s", frames[0])
+	}
+	acceptor.Write([]byte("reply"))
+
+	// A transient disconnect loses the frames in flight
+	dialer.Conn().Close()
+	acceptor.Conn().Close()
+	if err := dialer.Write([]byte("four")); err == nil {
+		t.Fatal("Write should fail while disconnected")
+	}
+
+	resumedDialer, resumedAcceptor, dialErr, acceptErr := connectSessions(t, dialer, cache, dialConfig, acceptConfig)
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Resume failed: %v / %v", dialErr, acceptErr)
+	}
+	if resumedDialer != dialer || resumedAcceptor != acceptor {
+		t.Fatal("The sessions should be resumed")
+	}
+	if frames := readFrames(t, acceptor, 3); fmt.Sprint(frames) != "[two three four]" {
+		t.Errorf("Expected the frames the acceptor did not receive, got %v", frames)
+	}
+	if frames := readFrames(t, dialer, 1); frames[0] != "reply" {
+		t.Errorf("Expected the frame the dialer did not receive, got %v", frames)
+	}
+	if dialConfig.RemoteUuid != "accept-uuid" || acceptConfig.RemoteUuid != "dial-uuid" {
+		t.Error("The resumed handshake should exchange the node information")
+	}
+}
+
+func TestSessionAcks(t *testing.T) {
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	dialer, acceptor, dialErr, acceptErr := connectSessions(t, nil, nets.NewSessionCache(acceptConfig),
+		newHandshakeConfig("dial-uuid", "dial", ""), acceptConfig)
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Session handshake failed: %v / %v", dialErr, acceptErr)
+	}
+	for i := 0; i < 2*nets.SessionAckInterval; i++ {
+		if err := dialer.Write([]byte(fmt.Sprint(i))); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if len(dialer.Unacknowledged()) != 2*nets.SessionAckInterval {
+		t.Fatal("The frames should be kept until acknowledged")
+	}
+	readFrames(t, acceptor, 2*nets.SessionAckInterval)
+	acceptor.Write([]byte("done"))
+	readFrames(t, dialer, 1)
+	if pending := len(dialer.Unacknowledged()); pending != 0 {
+		t.Errorf("Expected the acks to release the frames, %d left", pending)
+	}
+	if acceptor.Received() != 2*nets.SessionAckInterval || dialer.Received() != 1 {
+		t.Errorf("Unexpected received counts %d / %d", acceptor.Received(), dialer.Received())
+	}
+}
+
+func TestSessionNotResumed(t *testing.T) {
+	dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	cache := nets.NewSessionCache(acceptConfig)
+	dialer, _, dialErr, acceptErr := connectSessions(t, nil, cache, dialConfig, acceptConfig)
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Session handshake failed: %v / %v", dialErr, acceptErr)
+	}
+
+	// Another acceptor does not know the ticket and issues a new session
+	other, _, dialErr, acceptErr := connectSessions(t, dialer, nets.NewSessionCache(acceptConfig), dialConfig, acceptConfig)
+	if dialErr != nil || acceptErr != nil || other == dialer || other.Ticket() == dialer.Ticket() {
+		t.Errorf("An unknown ticket should start a new session: %v / %v", dialErr, acceptErr)
+	}
+
+	// The ticket belongs to another node
+	otherConfig := newHandshakeConfig("other-uuid", "other", "")
+	other, _, _, _ = connectSessions(t, dialer, cache, otherConfig, acceptConfig)
+	if other == dialer {
+		t.Error("The ticket of another node should not be resumed")
+	}
+
+	// A closed session cannot be resumed
+	dialer.Close()
+	cache.Remove(dialer.Ticket())
+	if other, _, _, _ = connectSessions(t, dialer, cache, dialConfig, acceptConfig); other == dialer {
+		t.Error("A removed session should not be resumed")
+	}
+}
+
+func TestSessionGap(t *testing.T) {
+	dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	cache := nets.NewSessionCache(acceptConfig)
+	dialer, _, dialErr, acceptErr := connectSessions(t, nil, cache, dialConfig, acceptConfig)
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Session handshake failed: %v / %v", dialErr, acceptErr)
+	}
+	dialer.Conn().Close()
+	for i := 0; i <= nets.SessionBufferSize; i++ {
+		dialer.Write([]byte("lost"))
+	}
+	if len(dialer.Unacknowledged()) != nets.SessionBufferSize {
+		t.Fatalf("Expected the buffer to keep %d frames", nets.SessionBufferSize)
+	}
+	_, _, dialErr, _ = connectSessions(t, dialer, cache, dialConfig, acceptConfig)
+	if !errors.Is(dialErr, nets.ErrSessionGap) {
+		t.Errorf("Expected ErrSessionGap when the frames were dropped, got %v", dialErr)
+	}
+}
+
+func TestSessionLegacyDialer(t *testing.T) {
+	dialConn, acceptConn := tcpPair(t)
+	security := &MockSecurityProviderNets{}
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	cache := nets.NewSessionCache(acceptConfig)
+	var session *nets.Session
+	dialErr, acceptErr := runHandshakes(
+		func() error {
+			return nets.Handshake(dialConn, newHandshakeConfig("dial-uuid", "dial", ""), security, true)
+		},
+		func() (err error) {
+			session, err = nets.AcceptSession(acceptConn, acceptConfig, security, cache)
+			return err
+		})
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Handshake failed: %v / %v", dialErr, acceptErr)
+	}
+	if session.Ticket() != "" || cache.Len() != 0 {
+		t.Error("A dialer without session support should not get a ticket")
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+	acceptConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	acceptConfig.SessionTicketTtlSeconds = 1
+	cache := nets.NewSessionCache(acceptConfig)
+	dialer, acceptor, dialErr, acceptErr := connectSessions(t, nil, cache, dialConfig, acceptConfig)
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Session handshake failed: %v / %v", dialErr, acceptErr)
+	}
+
+	// An idle session whose connection is alive does not expire
+	time.Sleep(1100 * time.Millisecond)
+	if _, _, dialErr, acceptErr = connectSessions(t, nil, cache, dialConfig, acceptConfig); dialErr != nil || acceptErr != nil {
+		t.Fatalf("Session handshake failed: %v / %v", dialErr, acceptErr)
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("An idle attached session should not be purged, %d sessions", cache.Len())
+	}
+
+	// Issuing a session purges the sessions detached for longer than the TTL
+	dialer.Conn().Close()
+	if _, err := acceptor.Read(); err == nil {
+		t.Fatal("Read should fail after the disconnect")
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if _, _, dialErr, acceptErr = connectSessions(t, nil, cache, dialConfig, acceptConfig); dialErr != nil || acceptErr != nil {
+		t.Fatalf("Session handshake failed: %v / %v", dialErr, acceptErr)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("The detached session should be purged, %d sessions", cache.Len())
+	}
+	if resumed, _, _, _ := connectSessions(t, dialer, cache, dialConfig, acceptConfig); resumed == dialer {
+		t.Error("An expired session should not be resumed")
+	}
+}
@@ -0,0 +1,140 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+func TestUnixSocketTransport(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("unix socket peer credentials are only supported on Linux")
+	}
+	path := filepath.Join(t.TempDir(), "vnet.sock")
+	listenConfig := newHandshakeConfig("accept-uuid", "accept", "")
+	listenConfig.UnixSocketPath = path
+	listener, err := nets.ListenUnix(listenConfig)
+	if err != nil {
+		t.Fatalf("ListenUnix failed: %v", err)
+	}
+	defer listener.Close()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Socket file should only be accessible to this user: %v", err)
+	}
+
+	dialConfig := newHandshakeConfig("dial-uuid", "dial", "")
+	dialConfig.UnixSocketPath = path
+	dialErr, acceptErr := runHandshakes(
+		func() error {
+			conn, err := nets.DialUnix(dialConfig)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			credentials, err := nets.PeerCredentials(conn)
+			if err != nil {
+				return err
+			}
+			if credentials.Uid != uint32(os.Getuid()) || credentials.Pid != int32(os.Getpid()) {
+				return errors.New("unexpected peer credentials")
+			}
+			return sec.NewShallowSecurityProvider().ValidateConnection(conn, dialConfig)
+		},
+		func() error {
+			conn, err := listener.Accept()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			return sec.NewShallowSecurityProvider().ValidateConnection(conn, listenConfig)
+		})
+	if dialErr != nil || acceptErr != nil {
+		t.Fatalf("Unix socket connection failed: %v / %v", dialErr, acceptErr)
+	}
+	if dialConfig.RemoteUuid != "accept-uuid" || listenConfig.RemoteUuid != "dial-uuid" {
+		t.Error("The handshake should run over the Unix socket")
+	}
+}
+
+func TestUnixSocketConfig(t *testing.T) {
+	config := &l8sysconfig.L8SysConfig{}
+	if nets.UsesUnixSocket(config) {
+		t.Error("TCP should be the default transport")
+	}
+	if _, err := nets.ListenUnix(config); err == nil {
+		t.Error("Expected error without a socket path")
+	}
+	if _, err := nets.DialUnix(config); err == nil {
+		t.Error("Expected error without a socket path")
+	}
+
+	dialConn, _ := tcpPair(t)
+	err := nets.AuthenticateUnix(dialConn, config)
+	var handshakeErr *nets.HandshakeError
+	if !errors.Is(err, nets.ErrUnixPeerCredentials) || !errors.As(err, &handshakeErr) || handshakeErr.Retryable() {
+		t.Errorf("Expected a non retryable ErrUnixPeerCredentials for a TCP connection, got %v", err)
+	}
+}
+
+func TestUnixSocketSelected(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("unix socket peer credentials are only supported on Linux")
+	}
+	config := newHandshakeConfig("dial-uuid", "dial", "")
+	config.UnixSocketPath = filepath.Join(t.TempDir(), "vnet.sock")
+	listener, err := nets.ListenUnix(config)
+	if err != nil {
+		t.Fatalf("ListenUnix failed: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			defer conn.Close()
+			conn.Read(make([]byte, 1))
+		}
+	}()
+
+	security := sec.NewShallowSecurityProviderWithUnixSocket("secret", config)
+	conn, err := security.CanDial("127.0.0.1", 1)
+	if err != nil {
+		t.Fatalf("CanDial should use the Unix socket of the config for this host: %v", err)
+	}
+	defer conn.Close()
+	if !nets.IsUnixConn(conn) {
+		t.Fatal("Expected a Unix socket connection")
+	}
+
+	// Peer credentials are checked under other decorators
+	wrapped := nets.NewFaultConn(nets.CaptureConn(conn, nil), nets.FaultScript{})
+	if !nets.IsUnixConn(wrapped) {
+		t.Error("A wrapped Unix socket connection should be detected")
+	}
+	if credentials, err := nets.PeerCredentials(wrapped); err != nil || credentials.Uid != uint32(os.Getuid()) {
+		t.Errorf("Expected the peer credentials through the decorators, got %v", err)
+	}
+	tcpConn, _ := tcpPair(t)
+	if nets.IsUnixConn(nets.NewFaultConn(tcpConn, nets.FaultScript{})) {
+		t.Error("A TCP connection is not a Unix socket connection")
+	}
+}
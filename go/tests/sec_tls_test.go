@@ -0,0 +1,196 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+)
+
+// newTLSTransport creates a transport with a node certificate of the uuid signed by the CA.
+func newTLSTransport(t *testing.T, caCert, caKey, uuid string) *sec.TLSTransport {
+	cert, key, err := sec.CreateNodeCert(caCert, caKey, uuid)
+	if err != nil {
+		t.Fatalf("CreateNodeCert failed: %v", err)
+	}
+	transport, err := sec.NewTLSTransport(cert, key, caCert)
+	if err != nil {
+		t.Fatalf("NewTLSTransport failed: %v", err)
+	}
+	return transport
+}
+
+// connectTLS dials the accepting provider over TLS and runs CanAccept and ValidateConnection
+// on both sides, returning the dialer and acceptor errors. The wrap function, if not nil,
+// decorates the accepted connection.
+func connectTLS(t *testing.T, dialer, acceptor *sec.ShallowSecurityProvider, acceptTransport *sec.TLSTransport,
+	dialUuid, acceptUuid string, wrap func(net.Conn) net.Conn) (error, error) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	listener := acceptTransport.WrapListener(tcpListener)
+	defer listener.Close()
+	port := uint32(tcpListener.Addr().(*net.TCPAddr).Port)
+
+	return runHandshakes(
+		func() error {
+			conn, err := dialer.CanDial("127.0.0.1", port)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			return dialer.ValidateConnection(conn, newHandshakeConfig(dialUuid, "dial", ""))
+		},
+		func() error {
+			conn, err := listener.Accept()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			if wrap != nil {
+				conn = wrap(conn)
+			}
+			if err = acceptor.CanAccept(conn); err != nil {
+				return err
+			}
+			config := newHandshakeConfig(acceptUuid, "accept", "")
+			if err = acceptor.ValidateConnection(conn, config); err != nil {
+				return err
+			}
+			if peer, _ := sec.PeerUuid(conn); peer != dialUuid {
+				return errors.New("unexpected peer uuid " + peer)
+			}
+			return nil
+		})
+}
+
+func TestTLSTransport(t *testing.T) {
+	caCert, caKey, err := sec.CreateCA()
+	if err != nil {
+		t.Fatalf("CreateCA failed: %v", err)
+	}
+	acceptTransport := newTLSTransport(t, caCert, caKey, "accept-uuid")
+	if acceptTransport.Uuid() != "accept-uuid" {
+		t.Errorf("Expected the certificate uuid, got %s", acceptTransport.Uuid())
+	}
+	acceptor := sec.NewShallowSecurityProviderWithTLS("secret", acceptTransport)
+
+	t.Run("Mutual", func(t *testing.T) {
+		dialer := sec.NewShallowSecurityProviderWithTLS("secret", newTLSTransport(t, caCert, caKey, "dial-uuid"))
+		dialErr, acceptErr := connectTLS(t, dialer, acceptor, acceptTransport, "dial-uuid", "accept-uuid", nil)
+		if dialErr != nil || acceptErr != nil {
+			t.Fatalf("TLS connection failed: %v / %v", dialErr, acceptErr)
+		}
+	})
+
+	t.Run("Wrapped", func(t *testing.T) {
+		dialer := sec.NewShallowSecurityProviderWithTLS("secret", newTLSTransport(t, caCert, caKey, "dial-uuid"))
+		capture, err := nets.NewCapture(io.Discard)
+		if err != nil {
+			t.Fatalf("NewCapture failed: %v", err)
+		}
+		wrap := func(conn net.Conn) net.Conn {
+			return nets.CaptureConn(nets.NewFaultConn(conn, nets.FaultScript{}), capture)
+		}
+		dialErr, acceptErr := connectTLS(t, dialer, acceptor, acceptTransport, "dial-uuid", "accept-uuid", wrap)
+		if dialErr != nil || acceptErr != nil {
+			t.Fatalf("A wrapped TLS connection failed: %v / %v", dialErr, acceptErr)
+		}
+	})
+
+	t.Run("IdentityMismatch", func(t *testing.T) {
+		dialer := sec.NewShallowSecurityProviderWithTLS("secret", newTLSTransport(t, caCert, caKey, "other-uuid"))
+		dialErr, acceptErr := connectTLS(t, dialer, acceptor, acceptTransport, "dial-uuid", "accept-uuid", nil)
+		if !errors.Is(dialErr, sec.ErrPeerIdentity) {
+			t.Errorf("Dialer should reject its own certificate of another uuid, got %v", dialErr)
+		}
+		if !errors.Is(acceptErr, sec.ErrPeerIdentity) {
+			t.Errorf("Acceptor should reject a certificate of another uuid, got %v", acceptErr)
+		}
+	})
+
+	t.Run("UntrustedCA", func(t *testing.T) {
+		otherCert, otherKey, err := sec.CreateCA()
+		if err != nil {
+			t.Fatalf("CreateCA failed: %v", err)
+		}
+		cert, key, _ := sec.CreateNodeCert(otherCert, otherKey, "dial-uuid")
+		untrusted, err := sec.NewTLSTransport(cert, key, caCert)
+		if err != nil {
+			t.Fatalf("NewTLSTransport failed: %v", err)
+		}
+		dialer := sec.NewShallowSecurityProviderWithTLS("secret", untrusted)
+		_, acceptErr := connectTLS(t, dialer, acceptor, acceptTransport, "dial-uuid", "accept-uuid", nil)
+		if acceptErr == nil {
+			t.Error("A client certificate from another CA should be rejected")
+		}
+	})
+
+	t.Run("NotTLS", func(t *testing.T) {
+		dialConn, _ := tcpPair(t)
+		if err := acceptor.CanAccept(dialConn); err == nil {
+			t.Error("A plain connection should be rejected")
+		}
+		if _, err := sec.PeerUuid(dialConn); err == nil {
+			t.Error("A plain connection has no peer uuid")
+		}
+	})
+
+	t.Run("HandshakeTimeout", func(t *testing.T) {
+		cert, key, _ := sec.CreateNodeCert(caCert, caKey, "accept-uuid")
+		transport, err := sec.NewTLSTransportWithConfig(cert, key, caCert,
+			&l8sysconfig.L8SysConfig{HandshakeTimeoutSeconds: 1})
+		if err != nil {
+			t.Fatalf("NewTLSTransportWithConfig failed: %v", err)
+		}
+		tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		listener := transport.WrapListener(tcpListener)
+		defer listener.Close()
+		// The client never starts the TLS handshake
+		silent, err := net.Dial("tcp", tcpListener.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer silent.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("Accept failed: %v", err)
+		}
+		defer conn.Close()
+		start := time.Now()
+		if err = transport.Accept(conn); err == nil {
+			t.Error("Accept should fail when the client does not complete the TLS handshake")
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Accept should give up after the config handshake timeout, took %s", elapsed)
+		}
+	})
+
+	if _, err = sec.NewTLSTransport("not base64", caKey, caCert); err == nil {
+		t.Error("Expected error for an invalid certificate")
+	}
+}
@@ -0,0 +1,181 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/nets"
+	"github.com/saichler/l8types/go/sec"
+	"github.com/saichler/l8types/go/simnet"
+)
+
+// newSimMessage creates a message from the source node to the destination uuid.
+func newSimMessage(source *simnet.Node, destination string, data string) *ifs.Message {
+	msg := &ifs.Message{}
+	msg.Init(destination, "sim-svc", 1, ifs.P1, ifs.M_All, ifs.POST,
+		source.Uuid(), "sim-vnet", []byte(data), true, false, 1,
+		ifs.NotATransaction, "", "", 0, 0, 0, 0, 0, 0, false)
+	return msg
+}
+
+// routeRecorder records the routes reported by the network hooks.
+type routeRecorder struct {
+	routes []simnet.Route
+	mtx    sync.Mutex
+}
+
+func (this *routeRecorder) onRoute(route *simnet.Route) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.routes = append(this.routes, *route)
+}
+
+func (this *routeRecorder) get() []simnet.Route {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return append([]simnet.Route(nil), this.routes...)
+}
+
+func TestSimNetStar(t *testing.T) {
+	network := simnet.NewNetwork("sim-secret")
+	defer network.Close()
+	recorder := &routeRecorder{}
+	network.OnRoute(recorder.onRoute)
+
+	hub := network.AddNode("hub")
+	a := network.AddNode("a")
+	b := network.AddNode("b")
+	b.AddService("b-svc", 3)
+	if err := network.Star(hub, a, b); err != nil {
+		t.Fatalf("Star failed: %v", err)
+	}
+
+	link := hub.Link(b.Uuid())
+	if link == nil || link.Config().RemoteAlias != "b" || !link.Config().Services.ServiceToAreas["b-svc"].Areas[3] {
+		t.Fatal("The handshake should exchange the alias and services")
+	}
+	if link.Config().WireVersion != uint32(ifs.WireVersionMax) {
+		t.Errorf("Expected wire version %d, got %d", ifs.WireVersionMax, link.Config().WireVersion)
+	}
+	if link.Config().HandshakeVersion != nets.HandshakeVersion {
+		t.Errorf("Expected the single round trip handshake, got version %d", link.Config().HandshakeVersion)
+	}
+
+	if err := a.Send(newSimMessage(a, b.Uuid(), "hello b")); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	msg, err := b.Receive(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg.Data()) != "hello b" || msg.Source() != a.Uuid() {
+		t.Errorf("Unexpected message %q from %s", msg.Data(), msg.Source())
+	}
+	if visited := msg.Visited(); len(visited) != 1 || visited[0] != hub.Uuid() {
+		t.Errorf("The message should be forwarded by the hub, visited %v", visited)
+	}
+
+	routes := recorder.get()
+	if len(routes) != 2 {
+		t.Fatalf("Expected 2 routes, got %d", len(routes))
+	}
+	if routes[0].From != a.Uuid() || routes[0].To != hub.Uuid() || routes[1].From != hub.Uuid() ||
+		routes[1].To != b.Uuid() || routes[0].Err != nil || routes[1].Err != nil {
+		t.Errorf("Unexpected routes %+v", routes)
+	}
+
+	// A handler receives the messages instead of the inbox
+	received := make(chan string, 1)
+	a.Handle(func(msg *ifs.Message) { received <- string(msg.Data()) })
+	if err = b.Send(newSimMessage(b, a.Uuid(), "hello a")); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	select {
+	case data := <-received:
+		if data != "hello a" {
+			t.Errorf("Unexpected data %q", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The handler was not called")
+	}
+}
+
+func TestSimNetRouting(t *testing.T) {
+	network := simnet.NewNetwork("sim-secret")
+	defer network.Close()
+	recorder := &routeRecorder{}
+	network.OnRoute(recorder.onRoute)
+	hub := network.AddNode("hub")
+	a := network.AddNode("a")
+	if err := network.Connect(a, hub); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	if err := a.Send(newSimMessage(a, ifs.NewUuid(), "")); !errors.Is(err, simnet.ErrNoRoute) {
+		t.Errorf("Expected ErrNoRoute, got %v", err)
+	}
+
+	// A misconfigured route bounces the message between a and the hub until it is dropped
+	missing := ifs.NewUuid()
+	a.AddRoute(missing, hub.Uuid())
+	hub.AddRoute(missing, a.Uuid())
+	if err := a.Send(newSimMessage(a, missing, "looping")); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	reply, err := a.Receive(time.Second)
+	if err != nil {
+		t.Fatalf("The dropped message should be answered: %v", err)
+	}
+	if !reply.Reply() || string(reply.Data()) != "looping" ||
+		!strings.Contains(reply.FailMessage(), ifs.ErrRoutingLoop.Error()) {
+		t.Errorf("Expected a fail reply giving the routing loop, got %q", reply.FailMessage())
+	}
+	routes := recorder.get()
+	if len(routes) < 3 || !errors.Is(routes[2].Err, ifs.ErrRoutingLoop) {
+		t.Errorf("Expected the routing loop to be detected on the third hop, got %+v", routes)
+	}
+
+	if err := network.Disconnect(a, hub); err != nil {
+		t.Fatalf("Disconnect failed: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for (a.Link(hub.Uuid()) != nil || hub.Link(a.Uuid()) != nil) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if a.Link(hub.Uuid()) != nil || hub.Link(a.Uuid()) != nil {
+		t.Error("Disconnect should remove the link from both nodes")
+	}
+}
+
+func TestSimNetBadSecret(t *testing.T) {
+	network := simnet.NewNetwork("sim-secret")
+	defer network.Close()
+	a := network.AddNode("a")
+	intruder := network.AddNodeWithSecurity("intruder", sec.NewShallowSecurityProviderWithSecret("other"))
+	var handshakeErr *nets.HandshakeError
+	if err := network.Connect(intruder, a); !errors.As(err, &handshakeErr) {
+		t.Fatalf("A node with another secret should be rejected, got %v", err)
+	}
+	if a.Link(intruder.Uuid()) != nil || intruder.Link(a.Uuid()) != nil {
+		t.Error("A rejected node should not be linked")
+	}
+}
@@ -0,0 +1,173 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/sec"
+)
+
+func TestStreamMessageRoundTrip(t *testing.T) {
+	resources := &versionResources{security: sec.NewShallowSecurityProvider()}
+	msg := newVersionTestMessage()
+	msg.SetVersion(ifs.WireVersion4)
+	msg.SetAction(ifs.StreamOpen)
+	msg.SetStream(msg.Sequence(), 16)
+
+	data, err := msg.Marshal(nil, resources)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	newMsg := &ifs.Message{}
+	if _, err = newMsg.Unmarshal(data, resources); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if newMsg.Action() != ifs.StreamOpen || newMsg.StreamId() != 77 || newMsg.StreamCredit() != 16 {
+		t.Errorf("Stream fields mismatch: %d %d %d", newMsg.Action(), newMsg.StreamId(), newMsg.StreamCredit())
+	}
+
+	reply := newMsg.CloneReply("local", "remote")
+	if reply.StreamId() != 77 || reply.StreamCredit() != 0 {
+		t.Error("Reply should keep the stream id without the granted credit")
+	}
+}
+
+func TestStreamWindow(t *testing.T) {
+	window := ifs.NewStreamWindow(2)
+	for i := 0; i < 2; i++ {
+		if err := window.Acquire(time.Second); err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+	}
+	if err := window.Acquire(10 * time.Millisecond); !errors.Is(err, ifs.ErrStreamTimeout) {
+		t.Errorf("Expected ErrStreamTimeout, got %v", err)
+	}
+
+	// A blocked sender resumes once credit is granted
+	done := make(chan error)
+	go func() { done <- window.Acquire(time.Second) }()
+	time.Sleep(10 * time.Millisecond)
+	window.Grant(3)
+	if err := <-done; err != nil {
+		t.Fatalf("Acquire after grant failed: %v", err)
+	}
+	if window.Credit() != 2 {
+		t.Errorf("Expected 2 credits left, got %d", window.Credit())
+	}
+
+	window.Close(nil)
+	if err := window.Acquire(time.Second); !errors.Is(err, ifs.ErrStreamClosed) {
+		t.Errorf("Expected ErrStreamClosed, got %v", err)
+	}
+}
+
+func TestStreamWindowWakesAll(t *testing.T) {
+	window := ifs.NewStreamWindow(0)
+	done := make(chan error, 6)
+	for i := 0; i < 3; i++ {
+		go func() { done <- window.Acquire(2 * time.Second) }()
+	}
+	time.Sleep(10 * time.Millisecond)
+	window.Grant(3)
+	for i := 0; i < 3; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("Every waiter should get a credit, got %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		go func() { done <- window.Acquire(2 * time.Second) }()
+	}
+	time.Sleep(10 * time.Millisecond)
+	start := time.Now()
+	window.Close(nil)
+	for i := 0; i < 3; i++ {
+		if err := <-done; !errors.Is(err, ifs.ErrStreamClosed) {
+			t.Errorf("Expected ErrStreamClosed, got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close should wake all the waiters, took %s", elapsed)
+	}
+}
+
+func TestStreamCredit(t *testing.T) {
+	credit := ifs.NewStreamCredit(8)
+	granted := uint32(0)
+	for i := 0; i < 8; i++ {
+		grant := credit.Consume()
+		if grant != 0 && grant != 4 {
+			t.Errorf("Expected grants of half the window, got %d", grant)
+		}
+		granted += grant
+	}
+	if granted != 8 {
+		t.Errorf("Expected the whole window granted back, got %d", granted)
+	}
+	if ifs.NewStreamCredit(1).Consume() != 1 {
+		t.Error("A window of one should grant after every message")
+	}
+}
+
+func TestStreamFlowControl(t *testing.T) {
+	const window = 4
+	const total = 50
+	sender := ifs.NewStreamWindow(window)
+	receiver := ifs.NewStreamCredit(window)
+	pipe := make(chan int, total)
+	var inFlight, maxInFlight int32
+
+	go func() {
+		for i := 0; i < total; i++ {
+			if err := sender.Acquire(time.Second); err != nil {
+				close(pipe)
+				return
+			}
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			pipe <- i
+		}
+		close(pipe)
+	}()
+
+	received := 0
+	for i := range pipe {
+		if i != received {
+			t.Fatalf("Expected %d, got %d", received, i)
+		}
+		received++
+		atomic.AddInt32(&inFlight, -1)
+		if grant := receiver.Consume(); grant > 0 {
+			sender.Grant(grant)
+		}
+	}
+	if received != total {
+		t.Errorf("Expected %d results, got %d", total, received)
+	}
+	if maxInFlight > window {
+		t.Errorf("Sender exceeded the credit window: %d in flight", maxInFlight)
+	}
+}
@@ -85,6 +85,28 @@ type L8SysConfig struct {
 	DataDirectory string `protobuf:"bytes,19,opt,name=data_directory,json=dataDirectory,proto3" json:"data_directory,omitempty"`
 	// DNS hostname for peer discovery (e.g., a K8s headless Service name)
 	DiscoveryDnsName string `protobuf:"bytes,20,opt,name=discovery_dns_name,json=discoveryDnsName,proto3" json:"discovery_dns_name,omitempty"`
+	// Message wire version negotiated with the remote side during the handshake
+	WireVersion uint32 `protobuf:"varint,21,opt,name=wire_version,json=wireVersion,proto3" json:"wire_version,omitempty"`
+	// Wire features negotiated with the remote side during the handshake
+	WireFeatures uint64 `protobuf:"varint,22,opt,name=wire_features,json=wireFeatures,proto3" json:"wire_features,omitempty"`
+	// Protocol version of the connection handshake used with the remote side, 0 for the legacy handshake
+	HandshakeVersion uint32 `protobuf:"varint,23,opt,name=handshake_version,json=handshakeVersion,proto3" json:"handshake_version,omitempty"`
+	// Time allowed for the connection handshake in Seconds, 0 for the default
+	HandshakeTimeoutSeconds int64 `protobuf:"varint,24,opt,name=handshake_timeout_seconds,json=handshakeTimeoutSeconds,proto3" json:"handshake_timeout_seconds,omitempty"`
+	// Keep alive beats missed in a row before the remote side is considered unreachable, 0 for the default
+	KeepAliveMissedBeats uint32 `protobuf:"varint,25,opt,name=keep_alive_missed_beats,json=keepAliveMissedBeats,proto3" json:"keep_alive_missed_beats,omitempty"`
+	// Unix domain socket path used instead of TCP on vnet_port for co-located nodes, empty for TCP
+	UnixSocketPath string `protobuf:"bytes,26,opt,name=unix_socket_path,json=unixSocketPath,proto3" json:"unix_socket_path,omitempty"`
+	// User ids allowed to connect over the Unix domain socket besides the user running this node
+	UnixSocketUids []uint32 `protobuf:"varint,27,rep,packed,name=unix_socket_uids,json=unixSocketUids,proto3" json:"unix_socket_uids,omitempty"`
+	// Rate limit of the messages received on each connection, unset for no limit
+	ConnectionRateLimit *L8RateLimit `protobuf:"bytes,28,opt,name=connection_rate_limit,json=connectionRateLimit,proto3" json:"connection_rate_limit,omitempty"`
+	// Rate limits of the messages received on each connection for a service, by service name
+	ServiceRateLimits map[string]*L8RateLimit `protobuf:"bytes,29,rep,name=service_rate_limits,json=serviceRateLimits,proto3" json:"service_rate_limits,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Time a session can be resumed after its connection failed in Seconds, 0 for the default
+	SessionTicketTtlSeconds int64 `protobuf:"varint,30,opt,name=session_ticket_ttl_seconds,json=sessionTicketTtlSeconds,proto3" json:"session_ticket_ttl_seconds,omitempty"`
+	// True if the node runs nets.Keepalive, so ifs.FeatureKeepalive is offered in the handshake
+	KeepAliveFrames bool `protobuf:"varint,31,opt,name=keep_alive_frames,json=keepAliveFrames,proto3" json:"keep_alive_frames,omitempty"`
 }
 
 func (x *L8SysConfig) Reset() {
@@ -259,6 +281,357 @@ func (x *L8SysConfig) GetDiscoveryDnsName() string {
 	return ""
 }
 
+func (x *L8SysConfig) GetWireVersion() uint32 {
+	if x != nil {
+		return x.WireVersion
+	}
+	return 0
+}
+
+func (x *L8SysConfig) GetWireFeatures() uint64 {
+	if x != nil {
+		return x.WireFeatures
+	}
+	return 0
+}
+
+func (x *L8SysConfig) GetHandshakeVersion() uint32 {
+	if x != nil {
+		return x.HandshakeVersion
+	}
+	return 0
+}
+
+func (x *L8SysConfig) GetHandshakeTimeoutSeconds() int64 {
+	if x != nil {
+		return x.HandshakeTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *L8SysConfig) GetKeepAliveMissedBeats() uint32 {
+	if x != nil {
+		return x.KeepAliveMissedBeats
+	}
+	return 0
+}
+
+func (x *L8SysConfig) GetUnixSocketPath() string {
+	if x != nil {
+		return x.UnixSocketPath
+	}
+	return ""
+}
+
+func (x *L8SysConfig) GetUnixSocketUids() []uint32 {
+	if x != nil {
+		return x.UnixSocketUids
+	}
+	return nil
+}
+
+func (x *L8SysConfig) GetConnectionRateLimit() *L8RateLimit {
+	if x != nil {
+		return x.ConnectionRateLimit
+	}
+	return nil
+}
+
+func (x *L8SysConfig) GetServiceRateLimits() map[string]*L8RateLimit {
+	if x != nil {
+		return x.ServiceRateLimits
+	}
+	return nil
+}
+
+func (x *L8SysConfig) GetSessionTicketTtlSeconds() int64 {
+	if x != nil {
+		return x.SessionTicketTtlSeconds
+	}
+	return 0
+}
+
+func (x *L8SysConfig) GetKeepAliveFrames() bool {
+	if x != nil {
+		return x.KeepAliveFrames
+	}
+	return false
+}
+
+// L8RateLimit is a token bucket limit on messages and bytes, a rate of 0 is not limited.
+type L8RateLimit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Messages allowed per second on average
+	MessagesPerSecond uint32 `protobuf:"varint,1,opt,name=messages_per_second,json=messagesPerSecond,proto3" json:"messages_per_second,omitempty"`
+	// Messages allowed at once after an idle period, 0 for messages_per_second
+	MessageBurst uint32 `protobuf:"varint,2,opt,name=message_burst,json=messageBurst,proto3" json:"message_burst,omitempty"`
+	// Bytes allowed per second on average
+	BytesPerSecond uint64 `protobuf:"varint,3,opt,name=bytes_per_second,json=bytesPerSecond,proto3" json:"bytes_per_second,omitempty"`
+	// Bytes allowed at once after an idle period, 0 for bytes_per_second
+	ByteBurst uint64 `protobuf:"varint,4,opt,name=byte_burst,json=byteBurst,proto3" json:"byte_burst,omitempty"`
+}
+
+func (x *L8RateLimit) Reset() {
+	*x = L8RateLimit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sysconfig_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *L8RateLimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*L8RateLimit) ProtoMessage() {}
+
+func (x *L8RateLimit) ProtoReflect() protoreflect.Message {
+	mi := &file_sysconfig_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use L8RateLimit.ProtoReflect.Descriptor instead.
+func (*L8RateLimit) Descriptor() ([]byte, []int) {
+	return file_sysconfig_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *L8RateLimit) GetMessagesPerSecond() uint32 {
+	if x != nil {
+		return x.MessagesPerSecond
+	}
+	return 0
+}
+
+func (x *L8RateLimit) GetMessageBurst() uint32 {
+	if x != nil {
+		return x.MessageBurst
+	}
+	return 0
+}
+
+func (x *L8RateLimit) GetBytesPerSecond() uint64 {
+	if x != nil {
+		return x.BytesPerSecond
+	}
+	return 0
+}
+
+func (x *L8RateLimit) GetByteBurst() uint64 {
+	if x != nil {
+		return x.ByteBurst
+	}
+	return 0
+}
+
+// L8Hello is sent by the dialing node to open a connection, carrying everything
+// the legacy handshake exchanged in separate round trips.
+type L8Hello struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Handshake protocol version of the sending node
+	ProtocolVersion uint32 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// Uuid of the sending node
+	Uuid string `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	// Alias of the sending node
+	Alias string `protobuf:"bytes,3,opt,name=alias,proto3" json:"alias,omitempty"`
+	// Force external mode, see L8SysConfig.force_external
+	ForceExternal bool `protobuf:"varint,4,opt,name=force_external,json=forceExternal,proto3" json:"force_external,omitempty"`
+	// Services provided by the sending node
+	Services *l8services.L8Services `protobuf:"bytes,5,opt,name=services,proto3" json:"services,omitempty"`
+	// VNet of the sending node, see L8SysConfig.remote_vnet
+	Vnet string `protobuf:"bytes,6,opt,name=vnet,proto3" json:"vnet,omitempty"`
+	// Wire capabilities of the sending node
+	Capabilities *l8services.L8WireCapabilities `protobuf:"bytes,7,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// Bit set of optional handshake features supported by the sending node
+	Features uint64 `protobuf:"varint,8,opt,name=features,proto3" json:"features,omitempty"`
+	// Session ticket, sent by the dialer to resume a session and by the acceptor to issue one
+	SessionTicket string `protobuf:"bytes,9,opt,name=session_ticket,json=sessionTicket,proto3" json:"session_ticket,omitempty"`
+	// Frames received by the sending node in the session, the rest are resent on resume
+	Received uint64 `protobuf:"varint,10,opt,name=received,proto3" json:"received,omitempty"`
+	// Proof that the sending node may connect, e.g. the shared secret, see ifs.ISecurityProviderProof
+	Proof []byte `protobuf:"bytes,11,opt,name=proof,proto3" json:"proof,omitempty"`
+}
+
+func (x *L8Hello) Reset() {
+	*x = L8Hello{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sysconfig_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *L8Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*L8Hello) ProtoMessage() {}
+
+func (x *L8Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_sysconfig_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use L8Hello.ProtoReflect.Descriptor instead.
+func (*L8Hello) Descriptor() ([]byte, []int) {
+	return file_sysconfig_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *L8Hello) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *L8Hello) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *L8Hello) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *L8Hello) GetForceExternal() bool {
+	if x != nil {
+		return x.ForceExternal
+	}
+	return false
+}
+
+func (x *L8Hello) GetServices() *l8services.L8Services {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+func (x *L8Hello) GetVnet() string {
+	if x != nil {
+		return x.Vnet
+	}
+	return ""
+}
+
+func (x *L8Hello) GetCapabilities() *l8services.L8WireCapabilities {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *L8Hello) GetFeatures() uint64 {
+	if x != nil {
+		return x.Features
+	}
+	return 0
+}
+
+func (x *L8Hello) GetSessionTicket() string {
+	if x != nil {
+		return x.SessionTicket
+	}
+	return ""
+}
+
+func (x *L8Hello) GetReceived() uint64 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *L8Hello) GetProof() []byte {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+// L8HelloAck is the reply of the accepting node to an L8Hello.
+type L8HelloAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The accepting node, with the handshake protocol version picked for the connection
+	Hello *L8Hello `protobuf:"bytes,1,opt,name=hello,proto3" json:"hello,omitempty"`
+	// True if the session of the dialer ticket was resumed
+	Resumed bool `protobuf:"varint,2,opt,name=resumed,proto3" json:"resumed,omitempty"`
+}
+
+func (x *L8HelloAck) Reset() {
+	*x = L8HelloAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sysconfig_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *L8HelloAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*L8HelloAck) ProtoMessage() {}
+
+func (x *L8HelloAck) ProtoReflect() protoreflect.Message {
+	mi := &file_sysconfig_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use L8HelloAck.ProtoReflect.Descriptor instead.
+func (*L8HelloAck) Descriptor() ([]byte, []int) {
+	return file_sysconfig_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *L8HelloAck) GetHello() *L8Hello {
+	if x != nil {
+		return x.Hello
+	}
+	return nil
+}
+
+func (x *L8HelloAck) GetResumed() bool {
+	if x != nil {
+		return x.Resumed
+	}
+	return false
+}
+
 type L8LogConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -271,7 +644,7 @@ type L8LogConfig struct {
 func (x *L8LogConfig) Reset() {
 	*x = L8LogConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sysconfig_proto_msgTypes[1]
+		mi := &file_sysconfig_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -284,7 +657,7 @@ func (x *L8LogConfig) String() string {
 func (*L8LogConfig) ProtoMessage() {}
 
 func (x *L8LogConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_sysconfig_proto_msgTypes[1]
+	mi := &file_sysconfig_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -297,7 +670,7 @@ func (x *L8LogConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use L8LogConfig.ProtoReflect.Descriptor instead.
 func (*L8LogConfig) Descriptor() ([]byte, []int) {
-	return file_sysconfig_proto_rawDescGZIP(), []int{1}
+	return file_sysconfig_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *L8LogConfig) GetLogDirectory() string {
@@ -327,7 +700,7 @@ type L8DataStoreConfig struct {
 func (x *L8DataStoreConfig) Reset() {
 	*x = L8DataStoreConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sysconfig_proto_msgTypes[2]
+		mi := &file_sysconfig_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -340,7 +713,7 @@ func (x *L8DataStoreConfig) String() string {
 func (*L8DataStoreConfig) ProtoMessage() {}
 
 func (x *L8DataStoreConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_sysconfig_proto_msgTypes[2]
+	mi := &file_sysconfig_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -353,7 +726,7 @@ func (x *L8DataStoreConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use L8DataStoreConfig.ProtoReflect.Descriptor instead.
 func (*L8DataStoreConfig) Descriptor() ([]byte, []int) {
-	return file_sysconfig_proto_rawDescGZIP(), []int{2}
+	return file_sysconfig_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *L8DataStoreConfig) GetType() string {
@@ -393,7 +766,7 @@ type L8WebAppConfig struct {
 func (x *L8WebAppConfig) Reset() {
 	*x = L8WebAppConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sysconfig_proto_msgTypes[3]
+		mi := &file_sysconfig_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -406,7 +779,7 @@ func (x *L8WebAppConfig) String() string {
 func (*L8WebAppConfig) ProtoMessage() {}
 
 func (x *L8WebAppConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_sysconfig_proto_msgTypes[3]
+	mi := &file_sysconfig_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -419,7 +792,7 @@ func (x *L8WebAppConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use L8WebAppConfig.ProtoReflect.Descriptor instead.
 func (*L8WebAppConfig) Descriptor() ([]byte, []int) {
-	return file_sysconfig_proto_rawDescGZIP(), []int{3}
+	return file_sysconfig_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *L8WebAppConfig) GetWebPort() uint32 {
@@ -469,8 +842,8 @@ var File_sysconfig_proto protoreflect.FileDescriptor
 var file_sysconfig_proto_rawDesc = []byte{
 	0x0a, 0x0f, 0x73, 0x79, 0x73, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x12, 0x0b, 0x6c, 0x38, 0x73, 0x79, 0x73, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x1a, 0x0e,
-	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xfa,
-	0x06, 0x0a, 0x0b, 0x4c, 0x38, 0x53, 0x79, 0x73, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x22,
+	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xae,
+	0x0c, 0x0a, 0x0b, 0x4c, 0x38, 0x53, 0x79, 0x73, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x22,
 	0x0a, 0x0d, 0x6d, 0x61, 0x78, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x44, 0x61, 0x74, 0x61, 0x53, 0x69,
 	0x7a, 0x65, 0x12, 0x22, 0x0a, 0x0d, 0x74, 0x78, 0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x5f, 0x73,
@@ -525,37 +898,121 @@ var file_sysconfig_proto_rawDesc = []byte{
 	0x64, 0x61, 0x74, 0x61, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x2c, 0x0a,
 	0x12, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x5f, 0x64, 0x6e, 0x73, 0x5f, 0x6e,
 	0x61, 0x6d, 0x65, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x64, 0x69, 0x73, 0x63, 0x6f,
-	0x76, 0x65, 0x72, 0x79, 0x44, 0x6e, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x4f, 0x0a, 0x0b, 0x4c,
-	0x38, 0x4c, 0x6f, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x6f,
-	0x67, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0c, 0x6c, 0x6f, 0x67, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x12,
-	0x1b, 0x0a, 0x09, 0x76, 0x6e, 0x65, 0x74, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0d, 0x52, 0x08, 0x76, 0x6e, 0x65, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x22, 0x4f, 0x0a, 0x11,
-	0x4c, 0x38, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72,
-	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x22, 0xef, 0x01,
-	0x0a, 0x0e, 0x4c, 0x38, 0x57, 0x65, 0x62, 0x41, 0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
-	0x12, 0x19, 0x0a, 0x08, 0x77, 0x65, 0x62, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0d, 0x52, 0x07, 0x77, 0x65, 0x62, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x65,
-	0x6e, 0x64, 0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x5f, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x65, 0x6e, 0x64, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x50,
-	0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x26, 0x0a, 0x0f, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x5f,
-	0x63, 0x65, 0x72, 0x74, 0x5f, 0x70, 0x65, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d,
-	0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x43, 0x65, 0x72, 0x74, 0x50, 0x65, 0x6d, 0x12, 0x26, 0x0a,
-	0x0f, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x70, 0x65, 0x6d,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b,
-	0x65, 0x79, 0x50, 0x65, 0x6d, 0x12, 0x24, 0x0a, 0x0e, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f,
-	0x6b, 0x65, 0x79, 0x5f, 0x70, 0x65, 0x6d, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70,
-	0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x50, 0x65, 0x6d, 0x12, 0x22, 0x0a, 0x0d, 0x63,
-	0x65, 0x72, 0x74, 0x5f, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x64, 0x69, 0x72, 0x18, 0x06, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x44, 0x69, 0x72, 0x42,
-	0x3b, 0x0a, 0x15, 0x63, 0x6f, 0x6d, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x6c, 0x38, 0x73,
-	0x79, 0x73, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x42, 0x0b, 0x4c, 0x38, 0x53, 0x79, 0x73, 0x63,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x50, 0x01, 0x5a, 0x13, 0x2e, 0x2f, 0x74, 0x79, 0x70, 0x65, 0x73,
-	0x2f, 0x6c, 0x38, 0x73, 0x79, 0x73, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x62, 0x06, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x33,
+	0x76, 0x65, 0x72, 0x79, 0x44, 0x6e, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x77,
+	0x69, 0x72, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x15, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0b, 0x77, 0x69, 0x72, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x23,
+	0x0a, 0x0d, 0x77, 0x69, 0x72, 0x65, 0x5f, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18,
+	0x16, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x77, 0x69, 0x72, 0x65, 0x46, 0x65, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x68, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65,
+	0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10,
+	0x68, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x3a, 0x0a, 0x19, 0x68, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x18, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x17, 0x68, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x54, 0x69,
+	0x6d, 0x65, 0x6f, 0x75, 0x74, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x35, 0x0a, 0x17,
+	0x6b, 0x65, 0x65, 0x70, 0x5f, 0x61, 0x6c, 0x69, 0x76, 0x65, 0x5f, 0x6d, 0x69, 0x73, 0x73, 0x65,
+	0x64, 0x5f, 0x62, 0x65, 0x61, 0x74, 0x73, 0x18, 0x19, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x14, 0x6b,
+	0x65, 0x65, 0x70, 0x41, 0x6c, 0x69, 0x76, 0x65, 0x4d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x42, 0x65,
+	0x61, 0x74, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x73, 0x6f, 0x63, 0x6b,
+	0x65, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x75,
+	0x6e, 0x69, 0x78, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x50, 0x61, 0x74, 0x68, 0x12, 0x28, 0x0a,
+	0x10, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x5f, 0x75, 0x69, 0x64,
+	0x73, 0x18, 0x1b, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x0e, 0x75, 0x6e, 0x69, 0x78, 0x53, 0x6f, 0x63,
+	0x6b, 0x65, 0x74, 0x55, 0x69, 0x64, 0x73, 0x12, 0x4c, 0x0a, 0x15, 0x63, 0x6f, 0x6e, 0x6e, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74,
+	0x18, 0x1c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6c, 0x38, 0x73, 0x79, 0x73, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x38, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x52, 0x13, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x61, 0x74, 0x65,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x5f, 0x0a, 0x13, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x5f, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x1d, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x6c, 0x38, 0x73, 0x79, 0x73, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x2e, 0x4c, 0x38, 0x53, 0x79, 0x73, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x11, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x52, 0x61, 0x74, 0x65,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x3b, 0x0a, 0x1a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x5f, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x5f, 0x74, 0x74, 0x6c, 0x5f, 0x73, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x73, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x17, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x54, 0x74, 0x6c, 0x53, 0x65, 0x63, 0x6f,
+	0x6e, 0x64, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x6b, 0x65, 0x65, 0x70, 0x5f, 0x61, 0x6c, 0x69, 0x76,
+	0x65, 0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f,
+	0x6b, 0x65, 0x65, 0x70, 0x41, 0x6c, 0x69, 0x76, 0x65, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x1a,
+	0x5e, 0x0a, 0x16, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69,
+	0x6d, 0x69, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2e, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6c, 0x38, 0x73,
+	0x79, 0x73, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x38, 0x52, 0x61, 0x74, 0x65, 0x4c,
+	0x69, 0x6d, 0x69, 0x74, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22,
+	0xab, 0x01, 0x0a, 0x0b, 0x4c, 0x38, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12,
+	0x2e, 0x0a, 0x13, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f,
+	0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x11, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12,
+	0x23, 0x0a, 0x0d, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x62, 0x75, 0x72, 0x73, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x42,
+	0x75, 0x72, 0x73, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x62, 0x79, 0x74, 0x65, 0x73, 0x5f, 0x70, 0x65,
+	0x72, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e,
+	0x62, 0x79, 0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12, 0x1d,
+	0x0a, 0x0a, 0x62, 0x79, 0x74, 0x65, 0x5f, 0x62, 0x75, 0x72, 0x73, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x09, 0x62, 0x79, 0x74, 0x65, 0x42, 0x75, 0x72, 0x73, 0x74, 0x22, 0x86, 0x03,
+	0x0a, 0x07, 0x4c, 0x38, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x69, 0x61,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x12, 0x25,
+	0x0a, 0x0e, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x5f, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x45, 0x78, 0x74,
+	0x65, 0x72, 0x6e, 0x61, 0x6c, 0x12, 0x32, 0x0a, 0x08, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6c, 0x38, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x73, 0x2e, 0x4c, 0x38, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x52,
+	0x08, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x76, 0x6e, 0x65,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x76, 0x6e, 0x65, 0x74, 0x12, 0x42, 0x0a,
+	0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x6c, 0x38, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73,
+	0x2e, 0x4c, 0x38, 0x57, 0x69, 0x72, 0x65, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74,
+	0x69, 0x65, 0x73, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65,
+	0x73, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x12, 0x25, 0x0a,
+	0x0e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x69, 0x63, 0x6b, 0x65, 0x74, 0x18,
+	0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x69,
+	0x63, 0x6b, 0x65, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64,
+	0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x22, 0x52, 0x0a, 0x0a, 0x4c, 0x38, 0x48, 0x65, 0x6c, 0x6c,
+	0x6f, 0x41, 0x63, 0x6b, 0x12, 0x2a, 0x0a, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6c, 0x38, 0x73, 0x79, 0x73, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x2e, 0x4c, 0x38, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x52, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f,
+	0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x64, 0x22, 0x4f, 0x0a, 0x0b, 0x4c, 0x38,
+	0x4c, 0x6f, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x6f, 0x67,
+	0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0c, 0x6c, 0x6f, 0x67, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x1b,
+	0x0a, 0x09, 0x76, 0x6e, 0x65, 0x74, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x08, 0x76, 0x6e, 0x65, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x22, 0x4f, 0x0a, 0x11, 0x4c,
+	0x38, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x22, 0xef, 0x01, 0x0a,
+	0x0e, 0x4c, 0x38, 0x57, 0x65, 0x62, 0x41, 0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12,
+	0x19, 0x0a, 0x08, 0x77, 0x65, 0x62, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x07, 0x77, 0x65, 0x62, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x65, 0x6e,
+	0x64, 0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x5f, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x65, 0x6e, 0x64, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x50, 0x72,
+	0x65, 0x66, 0x69, 0x78, 0x12, 0x26, 0x0a, 0x0f, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x5f, 0x63,
+	0x65, 0x72, 0x74, 0x5f, 0x70, 0x65, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x64,
+	0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x43, 0x65, 0x72, 0x74, 0x50, 0x65, 0x6d, 0x12, 0x26, 0x0a, 0x0f,
+	0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x70, 0x65, 0x6d, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65,
+	0x79, 0x50, 0x65, 0x6d, 0x12, 0x24, 0x0a, 0x0e, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b,
+	0x65, 0x79, 0x5f, 0x70, 0x65, 0x6d, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x75,
+	0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x50, 0x65, 0x6d, 0x12, 0x22, 0x0a, 0x0d, 0x63, 0x65,
+	0x72, 0x74, 0x5f, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x64, 0x69, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x44, 0x69, 0x72, 0x42, 0x3b,
+	0x0a, 0x15, 0x63, 0x6f, 0x6d, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x6c, 0x38, 0x73, 0x79,
+	0x73, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x42, 0x0b, 0x4c, 0x38, 0x53, 0x79, 0x73, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x50, 0x01, 0x5a, 0x13, 0x2e, 0x2f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2f,
+	0x6c, 0x38, 0x73, 0x79, 0x73, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -570,25 +1027,36 @@ func file_sysconfig_proto_rawDescGZIP() []byte {
 	return file_sysconfig_proto_rawDescData
 }
 
-var file_sysconfig_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_sysconfig_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_sysconfig_proto_goTypes = []interface{}{
-	(*L8SysConfig)(nil),           // 0: l8sysconfig.L8SysConfig
-	(*L8LogConfig)(nil),           // 1: l8sysconfig.L8LogConfig
-	(*L8DataStoreConfig)(nil),     // 2: l8sysconfig.L8DataStoreConfig
-	(*L8WebAppConfig)(nil),        // 3: l8sysconfig.L8WebAppConfig
-	(*l8services.L8Services)(nil), // 4: l8services.L8Services
+	(*L8SysConfig)(nil),                   // 0: l8sysconfig.L8SysConfig
+	(*L8RateLimit)(nil),                   // 1: l8sysconfig.L8RateLimit
+	(*L8Hello)(nil),                       // 2: l8sysconfig.L8Hello
+	(*L8HelloAck)(nil),                    // 3: l8sysconfig.L8HelloAck
+	(*L8LogConfig)(nil),                   // 4: l8sysconfig.L8LogConfig
+	(*L8DataStoreConfig)(nil),             // 5: l8sysconfig.L8DataStoreConfig
+	(*L8WebAppConfig)(nil),                // 6: l8sysconfig.L8WebAppConfig
+	nil,                                   // 7: l8sysconfig.L8SysConfig.ServiceRateLimitsEntry
+	(*l8services.L8Services)(nil),         // 8: l8services.L8Services
+	(*l8services.L8WireCapabilities)(nil), // 9: l8services.L8WireCapabilities
 }
 var file_sysconfig_proto_depIdxs = []int32{
-	4, // 0: l8sysconfig.L8SysConfig.services:type_name -> l8services.L8Services
-	1, // 1: l8sysconfig.L8SysConfig.log_config:type_name -> l8sysconfig.L8LogConfig
-	2, // 2: l8sysconfig.L8SysConfig.data_store_config:type_name -> l8sysconfig.L8DataStoreConfig
-	2, // 3: l8sysconfig.L8SysConfig.time_series_store_config:type_name -> l8sysconfig.L8DataStoreConfig
-	3, // 4: l8sysconfig.L8SysConfig.web_config:type_name -> l8sysconfig.L8WebAppConfig
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	8,  // 0: l8sysconfig.L8SysConfig.services:type_name -> l8services.L8Services
+	4,  // 1: l8sysconfig.L8SysConfig.log_config:type_name -> l8sysconfig.L8LogConfig
+	5,  // 2: l8sysconfig.L8SysConfig.data_store_config:type_name -> l8sysconfig.L8DataStoreConfig
+	5,  // 3: l8sysconfig.L8SysConfig.time_series_store_config:type_name -> l8sysconfig.L8DataStoreConfig
+	6,  // 4: l8sysconfig.L8SysConfig.web_config:type_name -> l8sysconfig.L8WebAppConfig
+	1,  // 5: l8sysconfig.L8SysConfig.connection_rate_limit:type_name -> l8sysconfig.L8RateLimit
+	7,  // 6: l8sysconfig.L8SysConfig.service_rate_limits:type_name -> l8sysconfig.L8SysConfig.ServiceRateLimitsEntry
+	8,  // 7: l8sysconfig.L8Hello.services:type_name -> l8services.L8Services
+	9,  // 8: l8sysconfig.L8Hello.capabilities:type_name -> l8services.L8WireCapabilities
+	2,  // 9: l8sysconfig.L8HelloAck.hello:type_name -> l8sysconfig.L8Hello
+	1,  // 10: l8sysconfig.L8SysConfig.ServiceRateLimitsEntry.value:type_name -> l8sysconfig.L8RateLimit
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_sysconfig_proto_init() }
@@ -610,7 +1078,7 @@ func file_sysconfig_proto_init() {
 			}
 		}
 		file_sysconfig_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*L8LogConfig); i {
+			switch v := v.(*L8RateLimit); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -622,7 +1090,7 @@ func file_sysconfig_proto_init() {
 			}
 		}
 		file_sysconfig_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*L8DataStoreConfig); i {
+			switch v := v.(*L8Hello); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -634,6 +1102,42 @@ func file_sysconfig_proto_init() {
 			}
 		}
 		file_sysconfig_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*L8HelloAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sysconfig_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*L8LogConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sysconfig_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*L8DataStoreConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sysconfig_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*L8WebAppConfig); i {
 			case 0:
 				return &v.state
@@ -652,7 +1156,7 @@ func file_sysconfig_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_sysconfig_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},